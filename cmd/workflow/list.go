@@ -0,0 +1,162 @@
+package main
+
+import (
+    "encoding/json"
+    "errors"
+    "flag"
+    "fmt"
+    "io"
+    "strconv"
+    "strings"
+
+    "workflow/internal/config"
+    "workflow/internal/query"
+    "workflow/internal/scanner"
+)
+
+var (
+    errUsage = errors.New("usage")
+    errHelp  = errors.New("help")
+)
+
+// repoFilter holds the boolean filter flags shared by headless commands.
+// All enabled flags must match (logical AND).
+type repoFilter struct {
+    dirty     bool
+    clean     bool
+    ahead     bool
+    behind    bool
+    conflicts bool
+    detached  bool
+    pkg       bool
+    noPkg     bool
+    hidden    bool
+    query     string
+}
+
+func (f *repoFilter) register(fs *flag.FlagSet) {
+    fs.BoolVar(&f.dirty, "dirty", false, "only repos with uncommitted changes")
+    fs.BoolVar(&f.clean, "clean", false, "only repos without uncommitted changes")
+    fs.BoolVar(&f.ahead, "ahead", false, "only repos ahead of upstream")
+    fs.BoolVar(&f.behind, "behind", false, "only repos behind upstream")
+    fs.BoolVar(&f.conflicts, "conflicts", false, "only repos with merge conflicts")
+    fs.BoolVar(&f.detached, "detached", false, "only repos with a detached HEAD")
+    fs.BoolVar(&f.pkg, "pkg", false, "only workspace packages")
+    fs.BoolVar(&f.noPkg, "no-pkg", false, "exclude workspace packages")
+    fs.BoolVar(&f.hidden, "hidden", false, "include repos hidden by overrides")
+    fs.StringVar(&f.query, "query", "", "filter query, same syntax as the TUI / filter (e.g. 'dirty or ahead>0')")
+}
+
+func (f *repoFilter) match(cfg config.Config, r scanner.RepoEntry) bool {
+    if !f.hidden && cfg.IsHidden(r.Path) { return false }
+    if f.dirty && !r.Dirty { return false }
+    if f.clean && r.Dirty { return false }
+    if f.ahead && r.Ahead == 0 { return false }
+    if f.behind && r.Behind == 0 { return false }
+    if f.conflicts && r.Conflicts == 0 { return false }
+    if f.detached && !r.Detached { return false }
+    if f.pkg && !r.WorkspacePkg { return false }
+    if f.noPkg && r.WorkspacePkg { return false }
+    return true
+}
+
+func (f *repoFilter) apply(cfg config.Config, in []scanner.RepoEntry) ([]scanner.RepoEntry, error) {
+    q, err := query.Parse(f.query)
+    if err != nil { return nil, fmt.Errorf("query: %w", err) }
+    out := make([]scanner.RepoEntry, 0, len(in))
+    for _, r := range in {
+        if !f.match(cfg, r) { continue }
+        if !q.Match(query.NewSubject(cfg, r, cfg.DisplayName(r.Path))) { continue }
+        out = append(out, r)
+    }
+    return out, nil
+}
+
+// parseFlags parses args and maps -h to a clean exit.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+    if err := fs.Parse(args); err != nil {
+        if errors.Is(err, flag.ErrHelp) { return errHelp }
+        return errUsage
+    }
+    if fs.NArg() > 0 {
+        fmt.Fprintf(fs.Output(), "unexpected argument: %s\n", fs.Arg(0))
+        return errUsage
+    }
+    return nil
+}
+
+func runList(cfg config.Config, args []string, w io.Writer) error {
+    fs := flag.NewFlagSet("list", flag.ContinueOnError)
+    format := fs.String("format", "json", "output format: json, ndjson or tsv")
+    var filt repoFilter
+    filt.register(fs)
+    if err := parseFlags(fs, args); err != nil {
+        if err == errHelp { return nil }
+        return err
+    }
+    switch *format {
+    case "json", "ndjson", "tsv":
+    default:
+        return fmt.Errorf("unknown format %q (want json, ndjson or tsv)", *format)
+    }
+    // validate the query before paying for a scan
+    if _, err := query.Parse(filt.query); err != nil { return fmt.Errorf("query: %w", err) }
+    entries, err := scanner.Scan(cfg)
+    if err != nil { return err }
+    entries, err = filt.apply(cfg, entries)
+    if err != nil { return err }
+    // every record has the same keys and types: [] rather than null
+    for i := range entries {
+        if entries[i].Unpushed == nil { entries[i].Unpushed = []scanner.BranchWork{} }
+    }
+    switch *format {
+    case "ndjson":
+        enc := json.NewEncoder(w)
+        for _, e := range entries {
+            if err := enc.Encode(e); err != nil { return err }
+        }
+        return nil
+    case "tsv":
+        return writeTSV(w, entries)
+    default:
+        enc := json.NewEncoder(w)
+        enc.SetIndent("", "  ")
+        return enc.Encode(entries)
+    }
+}
+
+// tsvColumns is the stable column order for TSV output; names match the JSON fields.
+var tsvColumns = []string{
+    "name", "path", "branch", "ahead", "behind", "dirty", "conflicts", "last_age",
+    "detached", "monorepo", "workspace_pkg", "package", "parent",
+    "staged", "unstaged", "untracked", "stashes", "upstream", "no_upstream", "operation", "unpushed",
+}
+
+func writeTSV(w io.Writer, entries []scanner.RepoEntry) error {
+    if _, err := fmt.Fprintln(w, strings.Join(tsvColumns, "\t")); err != nil { return err }
+    for _, e := range entries {
+        row := []string{
+            e.Name, e.Path, e.Branch, strconv.Itoa(e.Ahead), strconv.Itoa(e.Behind),
+            strconv.FormatBool(e.Dirty), strconv.Itoa(e.Conflicts), e.LastAge,
+            strconv.FormatBool(e.Detached), strconv.FormatBool(e.Monorepo),
+            strconv.FormatBool(e.WorkspacePkg), e.PackageName, e.ParentPath,
+            strconv.Itoa(e.Staged), strconv.Itoa(e.Unstaged), strconv.Itoa(e.Untracked),
+            strconv.Itoa(e.Stashes), e.Upstream, strconv.FormatBool(e.NoUpstream), e.Operation,
+            unpushedNames(e.Unpushed),
+        }
+        for i, v := range row { row[i] = tsvEscape(v) }
+        if _, err := fmt.Fprintln(w, strings.Join(row, "\t")); err != nil { return err }
+    }
+    return nil
+}
+
+// unpushedNames lists the branches with unpushed work, comma-separated.
+func unpushedNames(bs []scanner.BranchWork) string {
+    names := make([]string, len(bs))
+    for i, b := range bs { names[i] = b.Name }
+    return strings.Join(names, ",")
+}
+
+func tsvEscape(s string) string {
+    return strings.NewReplacer("\\", "\\\\", "\t", "\\t", "\n", "\\n", "\r", "\\r").Replace(s)
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+    "fmt"
     "log"
+    "os"
 
     tea "github.com/charmbracelet/bubbletea"
     "workflow/internal/config"
@@ -9,14 +11,52 @@ import (
     "workflow/internal/ui"
 )
 
+const usage = `usage: workflow [command] [flags]
+
+Without a command the interactive TUI is started.
+
+Commands:
+  list    print discovered repos as json, ndjson or tsv
+  sync    fetch repos and fast-forward the clean ones that are behind
+  help    show this help
+
+Run "workflow <command> -h" for command flags.
+`
+
 func main() {
     cfg, err := config.Load()
     if err != nil {
         log.Fatal(err)
     }
+    if len(os.Args) > 1 {
+        os.Exit(runCommand(cfg, os.Args[1], os.Args[2:]))
+    }
     th := theme.Detect(cfg.Theme)
     p := tea.NewProgram(ui.NewModel(cfg, th), tea.WithAltScreen())
     if err := p.Start(); err != nil {
         log.Fatal(err)
     }
 }
+
+// runCommand dispatches a headless subcommand and returns the process exit code.
+func runCommand(cfg config.Config, name string, args []string) int {
+    var err error
+    switch name {
+    case "list":
+        err = runList(cfg, args, os.Stdout)
+    case "sync":
+        err = runSync(cfg, args, os.Stdout)
+    case "help", "-h", "--help":
+        fmt.Fprint(os.Stdout, usage)
+        return 0
+    default:
+        fmt.Fprintf(os.Stderr, "workflow: unknown command %q\n\n%s", name, usage)
+        return 2
+    }
+    if err != nil {
+        if err == errUsage { return 2 }
+        fmt.Fprintln(os.Stderr, "workflow:", err)
+        return 1
+    }
+    return 0
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "flag"
+    "fmt"
+    "io"
+    "os"
+    "os/signal"
+    "text/tabwriter"
+    "time"
+
+    "github.com/mattn/go-isatty"
+    "workflow/internal/config"
+    "workflow/internal/gitsync"
+    "workflow/internal/scanner"
+)
+
+func runSync(cfg config.Config, args []string, w io.Writer) error {
+    fs := flag.NewFlagSet("sync", flag.ContinueOnError)
+    format := fs.String("format", "table", "output format: table, json or tsv")
+    jobs := fs.Int("jobs", cfg.Git.Jobs, "max repos synced concurrently")
+    timeout := fs.Duration("timeout", time.Duration(cfg.Git.TimeoutSeconds)*time.Second, "per-repo fetch/merge timeout")
+    noFetch := fs.Bool("no-fetch", false, "skip fetching; use the remote-tracking refs as they are")
+    dryRun := fs.Bool("dry-run", false, "report what would be fast-forwarded without merging")
+    var filt repoFilter
+    filt.register(fs)
+    if err := parseFlags(fs, args); err != nil {
+        if err == errHelp { return nil }
+        return err
+    }
+    switch *format {
+    case "table", "json", "tsv":
+    default:
+        return fmt.Errorf("unknown format %q (want table, json or tsv)", *format)
+    }
+    entries, err := scanner.Scan(cfg)
+    if err != nil { return err }
+    entries, err = filt.apply(cfg, entries)
+    if err != nil { return err }
+
+    ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+    defer stop()
+    opts := gitsync.Options{Jobs: *jobs, Fetch: !*noFetch, DryRun: *dryRun, Timeout: *timeout}
+    // progress counter only when a human is watching
+    tty := isatty.IsTerminal(os.Stderr.Fd())
+    done := 0
+    results := gitsync.Run(ctx, entries, opts, func(r gitsync.Result) {
+        done++
+        if tty { fmt.Fprintf(os.Stderr, "\rsyncing %d/%d", done, len(entries)) }
+    })
+    if tty && len(entries) > 0 { fmt.Fprintln(os.Stderr) }
+
+    if err := writeSyncResults(w, *format, results); err != nil { return err }
+    if n := countOutcome(results, gitsync.Failed); n > 0 {
+        return fmt.Errorf("%d of %d repos failed", n, len(results))
+    }
+    return nil
+}
+
+type syncRow struct {
+    Name    string `json:"name"`
+    Path    string `json:"path"`
+    Branch  string `json:"branch"`
+    Outcome string `json:"outcome"`
+    Reason  string `json:"reason,omitempty"`
+    Ahead   int    `json:"ahead"`
+    Behind  int    `json:"behind"`
+}
+
+func writeSyncResults(w io.Writer, format string, results []gitsync.Result) error {
+    rows := make([]syncRow, 0, len(results))
+    for _, r := range results {
+        rows = append(rows, syncRow{
+            Name: r.Entry.Name, Path: r.Entry.Path, Branch: r.Entry.Branch,
+            Outcome: string(r.Outcome), Reason: r.Reason, Ahead: r.Entry.Ahead, Behind: r.Entry.Behind,
+        })
+    }
+    switch format {
+    case "json":
+        enc := json.NewEncoder(w)
+        enc.SetIndent("", "  ")
+        return enc.Encode(rows)
+    case "tsv":
+        fmt.Fprintln(w, "name\tpath\tbranch\toutcome\treason\tahead\tbehind")
+        for _, r := range rows {
+            fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%d\n", tsvEscape(r.Name), tsvEscape(r.Path), tsvEscape(r.Branch),
+                r.Outcome, tsvEscape(r.Reason), r.Ahead, r.Behind)
+        }
+        return nil
+    }
+    tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+    fmt.Fprintln(tw, "REPO\tBRANCH\tOUTCOME\tDETAIL")
+    for _, r := range rows {
+        fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.Name, r.Branch, r.Outcome, r.Reason)
+    }
+    if err := tw.Flush(); err != nil { return err }
+    summary := fmt.Sprintf("%d updated", countOutcome(results, gitsync.Updated))
+    if n := countOutcome(results, gitsync.WouldUpdate); n > 0 { summary = fmt.Sprintf("%d would update", n) }
+    _, err := fmt.Fprintf(w, "\n%s, %d up-to-date, %d skipped, %d failed\n", summary,
+        countOutcome(results, gitsync.UpToDate), countOutcome(results, gitsync.Skipped),
+        countOutcome(results, gitsync.Failed))
+    return err
+}
+
+func countOutcome(results []gitsync.Result, o gitsync.Outcome) int {
+    n := 0
+    for _, r := range results {
+        if r.Outcome == o { n++ }
+    }
+    return n
+}
@@ -5,9 +5,12 @@ import (
     "strings"
 
     "workflow/internal/config"
+    "workflow/internal/shell"
 )
 
-// BuildAgentCommand returns a shell command string to launch the agent.
+// BuildAgentCommand returns a shell command string to launch the agent. In
+// the template, {cwd} is shell-quoted and {cmd} (the prelude and agent
+// command) is inserted as code.
 func BuildAgentCommand(agent string, cwd string, cfg config.Config) string {
     cmd := cfg.Agents.Map[agent]
     if cmd == "" {
@@ -20,14 +23,7 @@ func BuildAgentCommand(agent string, cwd string, cfg config.Config) string {
     if tpl == "" {
         tpl = "cd {cwd} && {cmd}"
     }
-    return strings.NewReplacer("{cwd}", cwd, "{cmd}", chain).Replace(tpl)
-}
-
-// BuildAlacrittyArgs builds an argument slice to run a shell command in a new
-// Alacritty window with working directory.
-func BuildAlacrittyArgs(cwd, shellCmd string) []string {
-    // alacritty --working-directory <cwd> -e bash -lc '<cmd>'
-    return []string{"--working-directory", cwd, "-e", "bash", "-lc", shellCmd}
+    return shell.Expand(tpl, map[string]string{"cwd": cwd, "cmd": chain}, "cmd")
 }
 
 func HasBinary(name string) bool {
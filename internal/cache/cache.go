@@ -13,8 +13,17 @@ type RootCache struct {
     Repos     []string `json:"repos"`
 }
 
+// RepoStatus is a status snapshot for one repo path. Entry holds the scanner's
+// JSON encoding of the row so this package does not depend on the scanner.
+type RepoStatus struct {
+    Fingerprint string          `json:"fingerprint"`
+    CheckedAt   int64           `json:"checked_at"`
+    Entry       json.RawMessage `json:"entry"`
+}
+
 type Data struct {
-    Roots map[string]RootCache `json:"roots"`
+    Roots  map[string]RootCache  `json:"roots"`
+    Status map[string]RepoStatus `json:"status,omitempty"`
 }
 
 func statePath() (string, error) {
@@ -32,6 +41,7 @@ func statePath() (string, error) {
 func Load() (Data, error) {
     var d Data
     d.Roots = map[string]RootCache{}
+    d.Status = map[string]RepoStatus{}
     p, err := statePath()
     if err != nil { return d, err }
     b, err := os.ReadFile(p)
@@ -41,6 +51,7 @@ func Load() (Data, error) {
     }
     if err := json.Unmarshal(b, &d); err != nil { return d, err }
     if d.Roots == nil { d.Roots = map[string]RootCache{} }
+    if d.Status == nil { d.Status = map[string]RepoStatus{} }
     return d, nil
 }
 
@@ -65,3 +76,21 @@ func PutRepos(d *Data, root string, repos []string) {
     d.Roots[root] = RootCache{ScannedAt: time.Now().Unix(), Repos: append([]string(nil), repos...)}
 }
 
+// GetStatus returns the cached status snapshot for a repo path.
+func GetStatus(d Data, path string) (RepoStatus, bool) {
+    st, ok := d.Status[path]
+    return st, ok
+}
+
+// PutStatus stores a status snapshot for a repo path.
+func PutStatus(d *Data, path string, fingerprint string, entry json.RawMessage) {
+    if d.Status == nil { d.Status = map[string]RepoStatus{} }
+    d.Status[path] = RepoStatus{Fingerprint: fingerprint, CheckedAt: time.Now().Unix(), Entry: entry}
+}
+
+// PruneStatus drops snapshots for paths not in keep.
+func PruneStatus(d *Data, keep map[string]struct{}) {
+    for p := range d.Status {
+        if _, ok := keep[p]; !ok { delete(d.Status, p) }
+    }
+}
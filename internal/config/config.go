@@ -5,6 +5,8 @@ import (
     "io/fs"
     "os"
     "path/filepath"
+    "sort"
+    "strings"
 
     "gopkg.in/yaml.v3"
 )
@@ -15,8 +17,26 @@ type Editors struct {
 }
 
 type Terminal struct {
+    // Prefer: alacritty, kitty, foot, wezterm, ghostty, gnome-terminal,
+    // konsole, xterm or "auto" (first one installed).
     Prefer      string `yaml:"prefer"`
+    // Template overrides the built-in profiles, e.g.
+    // "{term} --cwd {cwd} -e {shell} -c {cmd}".
+    Template    string `yaml:"template"`
     InPlaceShell string `yaml:"in_place_shell"`
+    // Mode: "new_window", "in_place" (suspend the TUI and run in this
+    // terminal), "mux" (tmux/zellij, see Mux) or "auto" (mux inside a
+    // multiplexer, in place over SSH or without a display).
+    Mode string `yaml:"mode"`
+    // Per-action modes: editor, shell, lazygit, agent, pager.
+    Modes map[string]string `yaml:"modes"`
+}
+
+// ModeFor returns the launch mode for action.
+func (t Terminal) ModeFor(action string) string {
+    if m := t.Modes[action]; m != "" { return m }
+    if t.Mode != "" { return t.Mode }
+    return "auto"
 }
 
 type Agents struct {
@@ -26,6 +46,53 @@ type Agents struct {
     CmdTemplate string            `yaml:"cmd_template"`
 }
 
+// Mux configures the tmux/zellij backend used by the "mux" launch mode.
+type Mux struct {
+    Backend string `yaml:"backend"` // tmux, zellij or auto
+    // Target inside a multiplexer: session (one per repo), window or pane.
+    Target string `yaml:"target"`
+}
+
+// Tasks controls how tasks picked with r are run.
+type Tasks struct {
+    // Mode: "inline" runs in a pane inside the TUI, "new_window" in a terminal
+    // window, "mux" in tmux/zellij, "auto" (default) mux inside a
+    // multiplexer, inline over SSH or without a display.
+    Mode string `yaml:"mode"`
+}
+
+// UI holds layout settings.
+type UI struct {
+    // PreviewMinWidth is the terminal width from which a preview of the
+    // highlighted repo is shown beside the table; negative turns it off.
+    PreviewMinWidth int `yaml:"preview_min_width"`
+}
+
+// Action is a user-defined command run for a repo. Cmd may use the
+// placeholders {path}, {name}, {branch}, {remote_url} and {package}, which
+// are shell-quoted when expanded.
+type Action struct {
+    Name string `yaml:"name"`
+    Key  string `yaml:"key"`
+    Cmd  string `yaml:"cmd"`
+    // Mode: "new_window", "in_place", "mux", "background" (a job in the J
+    // panel) or empty for terminal.modes.action / terminal.mode.
+    Mode    string `yaml:"mode"`
+    Confirm bool   `yaml:"confirm"` // ask y/N before running
+}
+
+// Label is the action's display name.
+func (a Action) Label() string {
+    if a.Name != "" { return a.Name }
+    return a.Cmd
+}
+
+// Git controls background git operations (fetch, sync).
+type Git struct {
+    Jobs           int `yaml:"jobs"`            // max concurrent repos
+    TimeoutSeconds int `yaml:"timeout_seconds"` // per-repo network timeout
+}
+
 type Config struct {
     Roots  []string `yaml:"roots"`
     Depth  int      `yaml:"depth"`
@@ -34,6 +101,11 @@ type Config struct {
     Editor   Editors  `yaml:"editor"`
     Terminal Terminal `yaml:"terminal"`
     Agents   Agents   `yaml:"agents"`
+    Git      Git      `yaml:"git"`
+    Tasks    Tasks    `yaml:"tasks"`
+    Mux      Mux      `yaml:"mux"`
+    UI       UI       `yaml:"ui"`
+    Actions  []Action `yaml:"actions"`
 
     Theme string `yaml:"theme"`
 
@@ -46,23 +118,14 @@ type Config struct {
     // Known badges: dirty, conflicts, ahead, behind, detached, mono, pkg
     BadgeColors map[string]string `yaml:"badge_colors"`
 
-    // Optional key remapping for common actions.
+    // Key remapping: action name -> key or list of keys (see Bindings).
     Keys Keymap `yaml:"keys"`
 }
 
-type Keymap struct {
-    Group       string `yaml:"group"`
-    Expand      string `yaml:"expand"`
-    Refresh     string `yaml:"refresh"`
-    Sort        string `yaml:"sort"`
-    SortReverse string `yaml:"sort_reverse"`
-    Details     string `yaml:"details"`
-    Tasks       string `yaml:"tasks"`
-}
-
 type RepoOverride struct {
-    Hidden      bool   `yaml:"hidden"`
-    DisplayName string `yaml:"name"`
+    Hidden      bool     `yaml:"hidden"`
+    DisplayName string   `yaml:"name"`
+    Tags        []string `yaml:"tags"` // free-form labels, matched by the tag: filter
 }
 
 func Default() Config {
@@ -75,8 +138,10 @@ func Default() Config {
             GUIFallbacks: []string{"cursor", "code"},
         },
         Terminal: Terminal{
-            Prefer:      "alacritty",
+            Prefer:      "auto",
             InPlaceShell: os.Getenv("SHELL"),
+            Mode:        "auto",
+            Modes:       map[string]string{},
         },
         Agents: Agents{
             Default:     "claude",
@@ -84,13 +149,15 @@ func Default() Config {
             Prelude:     []string{},
             CmdTemplate: "cd {cwd} && {cmd}",
         },
+        Git: Git{Jobs: 8, TimeoutSeconds: 120},
+        Tasks: Tasks{Mode: "auto"},
+        Mux: Mux{Backend: "auto", Target: "session"},
+        UI: UI{PreviewMinWidth: 140},
         Theme: "auto",
         Overrides: map[string]RepoOverride{},
         CacheTTLSeconds: 120,
         BadgeColors: map[string]string{},
-        Keys: Keymap{
-            Group: "m", Expand: "x", Refresh: "R", Sort: "s", SortReverse: "S", Details: "enter", Tasks: "r",
-        },
+        Keys: DefaultKeymap(),
     }
 }
 
@@ -132,26 +199,79 @@ func Load() (Config, error) {
     if user.Editor.Default != "" { merge.Editor.Default = user.Editor.Default }
     if len(user.Editor.GUIFallbacks) > 0 { merge.Editor.GUIFallbacks = user.Editor.GUIFallbacks }
     if user.Terminal.Prefer != "" { merge.Terminal.Prefer = user.Terminal.Prefer }
+    if user.Terminal.Template != "" { merge.Terminal.Template = user.Terminal.Template }
     if user.Terminal.InPlaceShell != "" { merge.Terminal.InPlaceShell = user.Terminal.InPlaceShell }
+    if user.Terminal.Mode != "" { merge.Terminal.Mode = user.Terminal.Mode }
+    if len(user.Terminal.Modes) > 0 { merge.Terminal.Modes = user.Terminal.Modes }
     if user.Agents.Default != "" { merge.Agents.Default = user.Agents.Default }
     if len(user.Agents.Map) > 0 { merge.Agents.Map = user.Agents.Map }
     if len(user.Agents.Prelude) > 0 { merge.Agents.Prelude = user.Agents.Prelude }
     if user.Agents.CmdTemplate != "" { merge.Agents.CmdTemplate = user.Agents.CmdTemplate }
+    if user.Git.Jobs != 0 { merge.Git.Jobs = user.Git.Jobs }
+    if user.Git.TimeoutSeconds != 0 { merge.Git.TimeoutSeconds = user.Git.TimeoutSeconds }
+    if user.Tasks.Mode != "" { merge.Tasks.Mode = user.Tasks.Mode }
+    if user.Mux.Backend != "" { merge.Mux.Backend = user.Mux.Backend }
+    if user.Mux.Target != "" { merge.Mux.Target = user.Mux.Target }
+    if user.UI.PreviewMinWidth != 0 { merge.UI.PreviewMinWidth = user.UI.PreviewMinWidth }
+    if len(user.Actions) > 0 { merge.Actions = user.Actions }
     if user.Theme != "" { merge.Theme = user.Theme }
     if len(user.Overrides) > 0 { merge.Overrides = user.Overrides }
     if user.CacheTTLSeconds != 0 { merge.CacheTTLSeconds = user.CacheTTLSeconds }
     if len(user.BadgeColors) > 0 { merge.BadgeColors = user.BadgeColors }
-    // merge keys individually so partial maps work
-    if user.Keys.Group != "" { merge.Keys.Group = user.Keys.Group }
-    if user.Keys.Expand != "" { merge.Keys.Expand = user.Keys.Expand }
-    if user.Keys.Refresh != "" { merge.Keys.Refresh = user.Keys.Refresh }
-    if user.Keys.Sort != "" { merge.Keys.Sort = user.Keys.Sort }
-    if user.Keys.SortReverse != "" { merge.Keys.SortReverse = user.Keys.SortReverse }
-    if user.Keys.Details != "" { merge.Keys.Details = user.Keys.Details }
-    if user.Keys.Tasks != "" { merge.Keys.Tasks = user.Keys.Tasks }
+    // keys merge per action so partial maps work
+    keys, err := resolveKeys(user.Keys, merge.Actions)
+    if err != nil { return cfg, err }
+    merge.Keys = keys
     return merge, nil
 }
 
+// IsHidden reports whether path is hidden by an exact or glob override.
+func (c Config) IsHidden(path string) bool {
+    if len(c.Overrides) == 0 { return false }
+    if ov, ok := c.Overrides[path]; ok {
+        if ov.Hidden { return true }
+    }
+    for pat, ov := range c.Overrides {
+        if strings.ContainsAny(pat, "*?[") {
+            if ok, _ := filepath.Match(pat, path); ok {
+                if ov.Hidden { return true }
+            }
+        }
+    }
+    return false
+}
+
+// DisplayName returns the name an exact or glob override gives path, or "".
+// An exact override wins; among globs the first matching pattern in sorted
+// order does.
+func (c Config) DisplayName(path string) string {
+    if len(c.Overrides) == 0 { return "" }
+    if ov, ok := c.Overrides[path]; ok { return ov.DisplayName }
+    pats := make([]string, 0, len(c.Overrides))
+    for pat := range c.Overrides { pats = append(pats, pat) }
+    sort.Strings(pats)
+    for _, pat := range pats {
+        if ov := c.Overrides[pat]; ov.DisplayName != "" && strings.ContainsAny(pat, "*?[") {
+            if ok, _ := filepath.Match(pat, path); ok { return ov.DisplayName }
+        }
+    }
+    return ""
+}
+
+// Tags returns the tags of every override (exact or glob) matching path.
+func (c Config) Tags(path string) []string {
+    var out []string
+    for pat, ov := range c.Overrides {
+        if len(ov.Tags) == 0 { continue }
+        if pat == path {
+            out = append(out, ov.Tags...)
+        } else if strings.ContainsAny(pat, "*?[") {
+            if ok, _ := filepath.Match(pat, path); ok { out = append(out, ov.Tags...) }
+        }
+    }
+    return out
+}
+
 // ExpandUser expands a path starting with ~ to the user's home.
 func ExpandUser(p string) string {
     if p == "" { return p }
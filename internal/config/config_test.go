@@ -0,0 +1,24 @@
+package config
+
+import "testing"
+
+func TestDisplayName(t *testing.T) {
+    c := Config{Overrides: map[string]RepoOverride{
+        "/w/api":      {DisplayName: "API"},
+        "/w/plain":    {Hidden: true},
+        "/w/*":        {DisplayName: "work"},
+        "/old/*":      {Tags: []string{"archived"}},
+        "/old/[ab]*":  {DisplayName: "old-ab"},
+    }}
+    tests := []struct{ path, want string }{
+        {"/w/api", "API"},
+        {"/w/web", "work"},
+        {"/w/plain", ""},
+        {"/old/alpha", "old-ab"},
+        {"/old/zeta", ""},
+        {"/elsewhere", ""},
+    }
+    for _, tt := range tests {
+        if got := c.DisplayName(tt.path); got != tt.want { t.Errorf("DisplayName(%q) = %q, want %q", tt.path, got, tt.want) }
+    }
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+    "fmt"
+    "slices"
+    "sort"
+
+    "gopkg.in/yaml.v3"
+)
+
+// KeyList is the keys bound to an action. YAML takes a single key or a list;
+// an empty list unbinds the action.
+type KeyList []string
+
+func (k *KeyList) UnmarshalYAML(n *yaml.Node) error {
+    if n.Kind == yaml.ScalarNode {
+        var s string
+        if err := n.Decode(&s); err != nil { return err }
+        *k = KeyList{s}
+        return nil
+    }
+    var l []string
+    if err := n.Decode(&l); err != nil { return err }
+    *k = l
+    return nil
+}
+
+// Keymap binds action names to keys. Actions missing from the user config
+// keep their default keys.
+type Keymap map[string]KeyList
+
+// Binding is a remappable action of the repo table.
+type Binding struct {
+    Action string
+    Keys   KeyList // defaults
+    Help   string  // label in the help footer
+}
+
+// Bindings lists every table action in help-footer order.
+var Bindings = []Binding{
+    {"details", KeyList{"enter"}, "details"},
+    {"filter", KeyList{"/"}, "filter"},
+    {"refresh", KeyList{"R"}, "refresh"},
+    {"sort", KeyList{"s"}, "sort"},
+    {"sort_reverse", KeyList{"S"}, "reverse sort"},
+    {"expand", KeyList{"x"}, "expand"},
+    {"select", KeyList{" "}, "select"},
+    {"select_all", KeyList{"v"}, "select all"},
+    {"invert", KeyList{"V"}, "invert"},
+    {"clear", KeyList{"esc"}, "clear selection"},
+    {"tasks", KeyList{"r"}, "tasks"},
+    {"docs", KeyList{"d"}, "docs"},
+    {"changes", KeyList{"c"}, "changes"},
+    {"branches", KeyList{"b"}, "branches"},
+    {"editor", KeyList{"e"}, "editor"},
+    {"gui_editor", KeyList{"E"}, "GUI editor"},
+    {"shell", KeyList{"o"}, "shell"},
+    {"lazygit", KeyList{"l"}, "lazygit"},
+    {"fetch", KeyList{"f"}, "fetch"},
+    {"fetch_all", KeyList{"F"}, "fetch all"},
+    {"pull", KeyList{"p"}, "pull"},
+    {"sync", KeyList{"P"}, "sync"},
+    {"agents", KeyList{"a"}, "agents"},
+    {"agent", KeyList{"A"}, "default agent"},
+    {"copy_path", KeyList{"y"}, "copy path"},
+    {"open_url", KeyList{"u"}, "open URL"},
+    {"copy_url", KeyList{"Y"}, "copy URL"},
+    {"jobs", KeyList{"J"}, "jobs"},
+    {"task_output", KeyList{"t"}, "task output"},
+    {"actions", KeyList{":"}, "actions"},
+    {"palette", KeyList{"ctrl+p"}, "palette"},
+    {"help", KeyList{"?"}, "help"},
+    {"quit", KeyList{"q", "ctrl+c"}, "quit"},
+}
+
+// Navigation is the table's own cursor keys. They aren't remappable and
+// nothing else may be bound to them.
+var Navigation = []struct {
+    Name string
+    Keys KeyList
+}{
+    {"line up", KeyList{"up", "k"}},
+    {"line down", KeyList{"down", "j"}},
+    {"page up", KeyList{"pgup"}},
+    {"page down", KeyList{"pgdown"}},
+    {"half page up", KeyList{"ctrl+u"}},
+    {"half page down", KeyList{"ctrl+d"}},
+    {"top", KeyList{"home", "g"}},
+    {"bottom", KeyList{"end", "G"}},
+}
+
+// DefaultKeymap returns the default key for every action.
+func DefaultKeymap() Keymap {
+    km := make(Keymap, len(Bindings))
+    for _, b := range Bindings { km[b.Action] = append(KeyList{}, b.Keys...) }
+    return km
+}
+
+// resolveKeys applies the user's keymap to the defaults and rejects unknown
+// actions and keys bound twice, including navigation keys and keys of custom
+// actions.
+func resolveKeys(user Keymap, actions []Action) (Keymap, error) {
+    km := DefaultKeymap()
+    names := make([]string, 0, len(user))
+    for name := range user { names = append(names, name) }
+    sort.Strings(names)
+    for _, name := range names {
+        // grouping is always on; the old key is still accepted
+        if name == "group" { continue }
+        if _, ok := km[name]; !ok { return nil, fmt.Errorf("keys: unknown action %q", name) }
+        km[name] = user[name]
+    }
+    owner := map[string]string{}
+    for _, n := range Navigation {
+        for _, k := range n.Keys { owner[k] = "navigation (" + n.Name + ")" }
+    }
+    for _, b := range Bindings {
+        for _, k := range km[b.Action] {
+            if prev, ok := owner[k]; ok && prev != b.Action {
+                return nil, fmt.Errorf("keys: %q is bound to both %s and %s", k, prev, b.Action)
+            }
+            owner[k] = b.Action
+        }
+    }
+    for _, a := range actions {
+        if a.Key == "" { continue }
+        if prev, ok := owner[a.Key]; ok {
+            return nil, fmt.Errorf("actions: key %q of %q is already bound to %s", a.Key, a.Label(), prev)
+        }
+        owner[a.Key] = "action " + a.Label()
+    }
+    return km, nil
+}
+
+// KeyOwner names what key is bound to in the table, a navigation key or an
+// action of km, or returns "" when the key is free.
+func KeyOwner(km Keymap, key string) string {
+    for _, n := range Navigation {
+        if slices.Contains(n.Keys, key) { return "navigation (" + n.Name + ")" }
+    }
+    for _, b := range Bindings {
+        if slices.Contains(km[b.Action], key) { return b.Action }
+    }
+    return ""
+}
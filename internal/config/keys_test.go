@@ -0,0 +1,113 @@
+package config
+
+import (
+    "slices"
+    "strings"
+    "testing"
+
+    "gopkg.in/yaml.v3"
+)
+
+func TestResolveKeys(t *testing.T) {
+    tests := []struct {
+        name    string
+        yaml    string // the keys: section
+        actions []Action
+        err     string            // substring of the error, "" for none
+        want    map[string]KeyList // checked bindings
+    }{
+        {
+            name: "defaults",
+            want: map[string]KeyList{"editor": {"e"}, "quit": {"q", "ctrl+c"}},
+        },
+        {
+            name: "single key",
+            yaml: "editor: n",
+            want: map[string]KeyList{"editor": {"n"}, "fetch": {"f"}},
+        },
+        {
+            name: "list-valued key",
+            yaml: "editor: [e, n]",
+            want: map[string]KeyList{"editor": {"e", "n"}},
+        },
+        {
+            name: "empty list unbinds",
+            yaml: "editor: []\nshell: e",
+            want: map[string]KeyList{"editor": {}, "shell": {"e"}},
+        },
+        {
+            name: "duplicate across actions",
+            yaml: "editor: f",
+            err:  `"f" is bound to both editor and fetch`,
+        },
+        {
+            name: "duplicate within a list",
+            yaml: "editor: [n, l]",
+            err:  `"l" is bound to both editor and lazygit`,
+        },
+        {
+            name: "navigation key collision",
+            yaml: "fetch: j",
+            err:  `"j" is bound to both navigation (line down) and fetch`,
+        },
+        {
+            name: "unknown action",
+            yaml: "deploy: D",
+            err:  `unknown action "deploy"`,
+        },
+        {
+            name: "old group key is accepted",
+            yaml: "group: g",
+        },
+        {
+            name:    "custom action on a free key",
+            actions: []Action{{Name: "deploy", Key: "D", Cmd: "true"}},
+        },
+        {
+            name:    "custom action on a bound key",
+            actions: []Action{{Name: "deploy", Key: "f", Cmd: "true"}},
+            err:     `key "f" of "deploy" is already bound to fetch`,
+        },
+        {
+            name:    "custom action on a navigation key",
+            actions: []Action{{Name: "deploy", Key: "G", Cmd: "true"}},
+            err:     `key "G" of "deploy" is already bound to navigation (bottom)`,
+        },
+        {
+            name:    "custom action on a key freed by unbinding",
+            yaml:    "fetch: []",
+            actions: []Action{{Name: "deploy", Key: "f", Cmd: "true"}},
+        },
+    }
+    for _, tt := range tests {
+        var user Keymap
+        if err := yaml.Unmarshal([]byte(tt.yaml), &user); err != nil { t.Fatalf("%s: %v", tt.name, err) }
+        km, err := resolveKeys(user, tt.actions)
+        if tt.err != "" {
+            if err == nil || !strings.Contains(err.Error(), tt.err) { t.Errorf("%s: error %v, want %q", tt.name, err, tt.err) }
+            continue
+        }
+        if err != nil {
+            t.Errorf("%s: %v", tt.name, err)
+            continue
+        }
+        for action, keys := range tt.want {
+            if !slices.Equal(km[action], keys) { t.Errorf("%s: %s = %q, want %q", tt.name, action, km[action], keys) }
+        }
+    }
+}
+
+func TestKeyOwner(t *testing.T) {
+    km := DefaultKeymap()
+    km["editor"] = KeyList{"e", "n"}
+    tests := []struct{ key, want string }{
+        {"f", "fetch"},
+        {"n", "editor"},
+        {"k", "navigation (line up)"},
+        {"end", "navigation (bottom)"},
+        {"Z", ""},
+    }
+    for _, tt := range tests {
+        if got := KeyOwner(km, tt.key); got != tt.want { t.Errorf("KeyOwner(%q) = %q, want %q", tt.key, got, tt.want) }
+    }
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+    "errors"
+    "fmt"
+    "io/fs"
+    "os"
+    "path/filepath"
+    "regexp"
+    "sort"
+    "sync"
+    "time"
+
+    "gopkg.in/yaml.v3"
+)
+
+// RepoFiles are the per-repo config locations, first match wins.
+var RepoFiles = []string{".workflow.yml", filepath.Join(".workflow", "config.yml")}
+
+// RepoConfig is a repo's own .workflow.yml.
+type RepoConfig struct {
+    Tasks   map[string]RepoTask `yaml:"tasks"`
+    Agent   string              `yaml:"agent"`   // default agent for A
+    Prelude []string            `yaml:"prelude"` // run before the agent, after agents.prelude
+    Docs    []string            `yaml:"docs"`    // files listed first in details and the d picker
+    Actions []Action            `yaml:"actions"`
+}
+
+// RepoTask is a named command. In YAML it is either the command string or
+// a mapping with cmd, cwd (relative to the repo) and env.
+type RepoTask struct {
+    Cmd string            `yaml:"cmd"`
+    Cwd string            `yaml:"cwd"`
+    Env map[string]string `yaml:"env"`
+}
+
+func (t *RepoTask) UnmarshalYAML(n *yaml.Node) error {
+    if n.Kind == yaml.ScalarNode { return n.Decode(&t.Cmd) }
+    type plain RepoTask
+    return n.Decode((*plain)(t))
+}
+
+// repoCache holds parsed repo configs by repo dir. Entries are reused while
+// the file keeps its path, mtime and size; the UI loads repo configs on
+// every key press and preview.
+var repoCache = struct {
+    sync.Mutex
+    m map[string]repoCached
+}{m: map[string]repoCached{}}
+
+type repoCached struct {
+    file  string
+    mtime time.Time
+    size  int64
+    rc    RepoConfig
+    err   error
+}
+
+// LoadRepo reads the repo config in dir. A repo without one yields a zero
+// RepoConfig and no error. The result is shared between callers and must not
+// be modified.
+func LoadRepo(dir string) (RepoConfig, error) {
+    for _, name := range RepoFiles {
+        file := filepath.Join(dir, name)
+        fi, err := os.Stat(file)
+        if errors.Is(err, fs.ErrNotExist) { continue }
+        if err != nil { return RepoConfig{}, err }
+        repoCache.Lock()
+        c, ok := repoCache.m[dir]
+        repoCache.Unlock()
+        if ok && c.file == file && c.mtime.Equal(fi.ModTime()) && c.size == fi.Size() { return c.rc, c.err }
+        c = repoCached{file: file, mtime: fi.ModTime(), size: fi.Size()}
+        c.rc, c.err = parseRepo(file, name)
+        repoCache.Lock()
+        repoCache.m[dir] = c
+        repoCache.Unlock()
+        return c.rc, c.err
+    }
+    return RepoConfig{}, nil
+}
+
+func parseRepo(file, name string) (RepoConfig, error) {
+    var rc RepoConfig
+    data, err := os.ReadFile(file)
+    if err != nil { return rc, err }
+    if err := yaml.Unmarshal(data, &rc); err != nil { return RepoConfig{}, fmt.Errorf("%s: %w", name, err) }
+    for _, n := range rc.TaskNames() {
+        env := rc.Tasks[n].Env
+        keys := make([]string, 0, len(env))
+        for k := range env { keys = append(keys, k) }
+        sort.Strings(keys)
+        for _, k := range keys {
+            if !envName.MatchString(k) { return RepoConfig{}, fmt.Errorf("%s: task %s: invalid env name %q", name, n, k) }
+        }
+    }
+    return rc, nil
+}
+
+// envName is what a task env key must look like; keys are exported by a
+// shell as written.
+var envName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// TaskNames returns the task names in sorted order.
+func (rc RepoConfig) TaskNames() []string {
+    names := make([]string, 0, len(rc.Tasks))
+    for n := range rc.Tasks { names = append(names, n) }
+    sort.Strings(names)
+    return names
+}
+
+// FindAction returns the action bound to key in actions.
+func FindAction(actions []Action, key string) (Action, bool) {
+    for _, a := range actions {
+        if a.Key == key && a.Cmd != "" { return a, true }
+    }
+    return Action{}, false
+}
+
+// Apply returns cfg with the repo's agent settings applied.
+func (rc RepoConfig) Apply(cfg Config) Config {
+    if rc.Agent != "" { cfg.Agents.Default = rc.Agent }
+    if len(rc.Prelude) > 0 {
+        cfg.Agents.Prelude = append(append([]string{}, cfg.Agents.Prelude...), rc.Prelude...)
+    }
+    return cfg
+}
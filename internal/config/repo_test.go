@@ -0,0 +1,34 @@
+package config
+
+import (
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+)
+
+func TestLoadRepoEnvNames(t *testing.T) {
+    tests := []struct {
+        env, err string
+    }{
+        {"{STAGE: staging, _X1: y}", ""},
+        {`{"A;id": x}`, `invalid env name "A;id"`},
+        {`{"1A": x}`, `invalid env name "1A"`},
+        {`{"A B": x}`, `invalid env name "A B"`},
+        {`{"": x}`, `invalid env name ""`},
+    }
+    for _, tt := range tests {
+        dir := t.TempDir()
+        yml := "tasks:\n  deploy:\n    cmd: ./deploy.sh\n    env: " + tt.env + "\n"
+        if err := os.WriteFile(filepath.Join(dir, ".workflow.yml"), []byte(yml), 0o644); err != nil { t.Fatal(err) }
+        rc, err := LoadRepo(dir)
+        switch {
+        case tt.err == "" && err != nil:
+            t.Errorf("env %s: %v", tt.env, err)
+        case tt.err == "" && rc.Tasks["deploy"].Cmd != "./deploy.sh":
+            t.Errorf("env %s: task not loaded: %+v", tt.env, rc)
+        case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+            t.Errorf("env %s: error %v, want %q", tt.env, err, tt.err)
+        }
+    }
+}
@@ -0,0 +1,138 @@
+// Package gitsync brings many repos up to date: fetch, then fast-forward the
+// ones that can be updated safely and report why the others were skipped.
+package gitsync
+
+import (
+    "context"
+    "fmt"
+    "os/exec"
+    "sync"
+    "time"
+
+    "workflow/internal/gitutil"
+    "workflow/internal/scanner"
+)
+
+type Outcome string
+
+const (
+    Updated     Outcome = "updated"
+    UpToDate    Outcome = "up-to-date"
+    WouldUpdate Outcome = "would-update" // dry run
+    Skipped     Outcome = "skipped"
+    Failed      Outcome = "failed"
+)
+
+// Result is the outcome of syncing one repo. Entry is its status afterwards.
+type Result struct {
+    Entry   scanner.RepoEntry
+    Outcome Outcome
+    Reason  string
+}
+
+type Options struct {
+    Jobs    int           // max concurrent repos (default 8)
+    Fetch   bool          // fetch before deciding
+    DryRun  bool          // report what would happen without merging
+    Timeout time.Duration // per-repo limit for network operations (0 = none)
+    // Exec runs the git commands (e.g. to track them); nil runs them directly.
+    Exec func(e scanner.RepoEntry, label string, cmd *exec.Cmd) ([]byte, error)
+}
+
+// git runs a git subcommand for e through opts.Exec.
+func (opts Options) git(ctx context.Context, e scanner.RepoEntry, label string, args ...string) error {
+    cmd := gitutil.Command(ctx, e.Path, args...)
+    var out []byte
+    var err error
+    if opts.Exec != nil {
+        out, err = opts.Exec(e, label, cmd)
+    } else {
+        out, err = cmd.CombinedOutput()
+    }
+    return gitutil.CommandError(ctx, out, err)
+}
+
+// Run syncs entries with bounded concurrency. Results keep the input order;
+// progress, if set, is called as each repo finishes (serialized).
+func Run(ctx context.Context, entries []scanner.RepoEntry, opts Options, progress func(Result)) []Result {
+    jobs := opts.Jobs
+    if jobs <= 0 { jobs = 8 }
+    out := make([]Result, len(entries))
+    var mu sync.Mutex
+    var wg sync.WaitGroup
+    sem := make(chan struct{}, jobs)
+    for i, e := range entries {
+        i, e := i, e
+        wg.Add(1)
+        sem <- struct{}{}
+        go func() {
+            defer wg.Done()
+            defer func() { <-sem }()
+            r := One(ctx, e, opts)
+            out[i] = r
+            if progress != nil {
+                mu.Lock()
+                progress(r)
+                mu.Unlock()
+            }
+        }()
+    }
+    wg.Wait()
+    return out
+}
+
+// One syncs a single repo. Only clean, attached branches with an upstream
+// that are strictly behind are fast-forwarded.
+func One(ctx context.Context, e scanner.RepoEntry, opts Options) Result {
+    if e.WorkspacePkg {
+        return Result{Entry: e, Outcome: Skipped, Reason: "workspace package, synced with its repo"}
+    }
+    if opts.Fetch {
+        fctx, cancel := withTimeout(ctx, opts.Timeout)
+        err := opts.git(fctx, e, "fetch", "fetch", "--all", "--prune")
+        cancel()
+        if err != nil {
+            return Result{Entry: e, Outcome: Failed, Reason: "fetch: " + err.Error()}
+        }
+        e = scanner.Refresh(e)
+    }
+    switch {
+    case e.Detached:
+        return Result{Entry: e, Outcome: Skipped, Reason: "detached HEAD"}
+    case e.Conflicts > 0:
+        return Result{Entry: e, Outcome: Skipped, Reason: fmt.Sprintf("%d conflicts", e.Conflicts)}
+    case gitutil.Upstream(e.Path) == "":
+        return Result{Entry: e, Outcome: Skipped, Reason: "no upstream"}
+    case e.Behind == 0:
+        if e.Ahead > 0 {
+            return Result{Entry: e, Outcome: UpToDate, Reason: fmt.Sprintf("%d to push", e.Ahead)}
+        }
+        return Result{Entry: e, Outcome: UpToDate}
+    case e.Ahead > 0:
+        return Result{Entry: e, Outcome: Skipped, Reason: fmt.Sprintf("diverged (%d ahead, %d behind)", e.Ahead, e.Behind)}
+    case e.Dirty:
+        return Result{Entry: e, Outcome: Skipped, Reason: fmt.Sprintf("dirty working tree (%d behind)", e.Behind)}
+    }
+    behind := e.Behind
+    if opts.DryRun {
+        return Result{Entry: e, Outcome: WouldUpdate, Reason: commits(behind)}
+    }
+    mctx, cancel := withTimeout(ctx, opts.Timeout)
+    err := opts.git(mctx, e, "sync", "merge", "--ff-only", "@{upstream}")
+    cancel()
+    e = scanner.Refresh(e)
+    if err != nil {
+        return Result{Entry: e, Outcome: Failed, Reason: err.Error()}
+    }
+    return Result{Entry: e, Outcome: Updated, Reason: "fast-forwarded " + commits(behind)}
+}
+
+func commits(n int) string {
+    if n == 1 { return "1 commit" }
+    return fmt.Sprintf("%d commits", n)
+}
+
+func withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+    if d <= 0 { return context.WithCancel(ctx) }
+    return context.WithTimeout(ctx, d)
+}
@@ -0,0 +1,167 @@
+package gitutil
+
+import (
+    "context"
+    "fmt"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+)
+
+// Branch is a local or remote-tracking branch, compared with the repo's
+// default branch.
+type Branch struct {
+    Name     string // short name: main, origin/feat
+    Remote   bool
+    Current  bool
+    Upstream string
+    Time     time.Time // last commit
+    Ahead    int       // commits not in the default branch
+    Behind   int       // commits of the default branch not in this one
+    Merged   bool      // contained in the default branch
+}
+
+// DefaultBranch returns the branch others are compared with: the remote's
+// HEAD (e.g. origin/main) when known, else a local main or master, else "".
+func DefaultBranch(path string) string {
+    if out, err := git(path, "symbolic-ref", "-q", "--short", "refs/remotes/origin/HEAD"); err == nil && out != "" {
+        return out
+    }
+    for _, b := range []string{"main", "master"} {
+        if _, err := git(path, "rev-parse", "-q", "--verify", "refs/heads/"+b); err == nil { return b }
+    }
+    return ""
+}
+
+// branchCountJobs bounds the rev-lists Branches runs at once when git can't
+// count ahead/behind itself.
+const branchCountJobs = 8
+
+// Branches lists the local branches, then the remote-tracking ones, most
+// recently committed first, with ahead/behind counts against base. The
+// counts come from for-each-ref's ahead-behind (git 2.41+), else from a
+// rev-list per branch.
+func Branches(path, base string) ([]Branch, error) {
+    format := "%(refname)%00%(refname:short)%00%(committerdate:unix)%00%(HEAD)%00%(upstream:short)"
+    var out string
+    counted := false
+    if base != "" {
+        var err error
+        out, err = git(path, "for-each-ref", "--sort=-committerdate", "--format="+format+"%00%(ahead-behind:"+base+")", "refs/heads", "refs/remotes")
+        counted = err == nil
+    }
+    if !counted {
+        var err error
+        out, err = git(path, "for-each-ref", "--sort=-committerdate", "--format="+format, "refs/heads", "refs/remotes")
+        if err != nil { return nil, err }
+    }
+    var local, remote []Branch
+    var localRefs, remoteRefs []string // full ref names, parallel to local and remote
+    for _, ln := range strings.Split(out, "\n") {
+        f := strings.Split(ln, "\x00")
+        if len(f) < 5 { continue }
+        // origin/HEAD only points at another branch
+        if strings.HasPrefix(f[0], "refs/remotes/") && strings.HasSuffix(f[0], "/HEAD") { continue }
+        b := Branch{Name: f[1], Remote: strings.HasPrefix(f[0], "refs/remotes/"), Current: f[3] == "*", Upstream: f[4]}
+        if sec, err := strconv.ParseInt(f[2], 10, 64); err == nil { b.Time = time.Unix(sec, 0) }
+        if counted && len(f) == 6 && b.Name != base {
+            if ab := strings.Fields(f[5]); len(ab) == 2 {
+                b.Ahead, _ = strconv.Atoi(ab[0])
+                b.Behind, _ = strconv.Atoi(ab[1])
+            }
+            b.Merged = b.Ahead == 0
+        }
+        if b.Remote {
+            remote = append(remote, b)
+            remoteRefs = append(remoteRefs, f[0])
+        } else {
+            local = append(local, b)
+            localRefs = append(localRefs, f[0])
+        }
+    }
+    bs := append(local, remote...)
+    if base != "" && !counted { countAheadBehind(path, base, bs, append(localRefs, remoteRefs...)) }
+    return bs, nil
+}
+
+// countAheadBehind fills in the counts of bs against base, branchCountJobs
+// rev-lists at a time.
+func countAheadBehind(path, base string, bs []Branch, refs []string) {
+    var wg sync.WaitGroup
+    sem := make(chan struct{}, branchCountJobs)
+    for i := range bs {
+        if bs[i].Name == base { continue }
+        wg.Add(1)
+        sem <- struct{}{}
+        go func() {
+            defer wg.Done()
+            defer func() { <-sem }()
+            bs[i].Behind, bs[i].Ahead = aheadBehind(path, base, refs[i])
+            bs[i].Merged = bs[i].Ahead == 0
+        }()
+    }
+    wg.Wait()
+}
+
+// aheadBehind counts the commits only in base and only in ref.
+func aheadBehind(path, base, ref string) (int, int) {
+    out, err := git(path, "rev-list", "--left-right", "--count", base+"..."+ref)
+    if err != nil { return 0, 0 }
+    f := strings.Fields(out)
+    if len(f) != 2 { return 0, 0 }
+    l, _ := strconv.Atoi(f[0])
+    r, _ := strconv.Atoi(f[1])
+    return l, r
+}
+
+// Checkout switches to branch. A remote-tracking branch (origin/feat) gets
+// a local branch tracking it, unless one with that name exists.
+func Checkout(path string, b Branch) error {
+    if !b.Remote { return run(path, "switch", "--end-of-options", b.Name) }
+    _, name, _ := strings.Cut(b.Name, "/")
+    if _, err := git(path, "rev-parse", "-q", "--verify", "refs/heads/"+name); err == nil {
+        return run(path, "switch", "--end-of-options", name)
+    }
+    return run(path, "switch", "--track", "--end-of-options", b.Name)
+}
+
+// CheckBranchName returns an error unless name is valid for a new branch;
+// it also keeps names that git would read as options out of its argv.
+func CheckBranchName(path, name string) error {
+    if _, err := git(path, "check-ref-format", "--branch", name); err != nil {
+        return fmt.Errorf("%q is not a valid branch name", name)
+    }
+    return nil
+}
+
+// CreateBranch creates name at HEAD and switches to it.
+func CreateBranch(path, name string) error {
+    if err := CheckBranchName(path, name); err != nil { return err }
+    return run(path, "switch", "-c", name)
+}
+
+// RenameBranch renames a local branch.
+func RenameBranch(path, from, to string) error {
+    if err := CheckBranchName(path, to); err != nil { return err }
+    return run(path, "branch", "-m", "--end-of-options", from, to)
+}
+
+// DeleteBranch deletes a local branch once it is merged into base. git's own
+// check only looks at HEAD and the upstream, so this checks base itself, at
+// delete time rather than when the list was loaded.
+func DeleteBranch(path, name, base string) error {
+    if base == "" { return fmt.Errorf("no default branch to check %s against", name) }
+    if _, err := git(path, "merge-base", "--is-ancestor", "refs/heads/"+name, base); err != nil {
+        return fmt.Errorf("%s has commits not in %s", name, base)
+    }
+    return run(path, "branch", "-D", "--end-of-options", name)
+}
+
+// git runs a local git command and returns its trimmed output.
+func git(path string, args ...string) (string, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), worktreeTimeout)
+    defer cancel()
+    out, err := Command(ctx, path, args...).Output()
+    return strings.TrimSpace(string(out)), err
+}
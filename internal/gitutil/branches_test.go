@@ -0,0 +1,111 @@
+package gitutil
+
+import (
+    "os/exec"
+    "path/filepath"
+    "strings"
+    "testing"
+)
+
+// testRepo clones a fresh bare origin with one commit on main and returns
+// the clone's path and a git runner for it.
+func testRepo(t *testing.T) (string, func(args ...string) string) {
+    t.Helper()
+    if _, err := exec.LookPath("git"); err != nil { t.Skip("no git") }
+    for k, v := range map[string]string{
+        "GIT_AUTHOR_NAME": "t", "GIT_AUTHOR_EMAIL": "t@t", "GIT_COMMITTER_NAME": "t", "GIT_COMMITTER_EMAIL": "t@t",
+        "GIT_CONFIG_GLOBAL": "/dev/null", "GIT_CONFIG_NOSYSTEM": "1",
+    } {
+        t.Setenv(k, v)
+    }
+    tmp := t.TempDir()
+    run := func(dir string, args ...string) string {
+        t.Helper()
+        out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+        if err != nil { t.Fatalf("git %v: %v\n%s", args, err, out) }
+        return strings.TrimSpace(string(out))
+    }
+    origin, clone := filepath.Join(tmp, "origin.git"), filepath.Join(tmp, "clone")
+    run(tmp, "init", "-q", "--bare", "-b", "main", origin)
+    run(tmp, "clone", "-q", origin, clone)
+    run(clone, "commit", "-q", "--allow-empty", "-m", "init")
+    run(clone, "push", "-q", "-u", "origin", "main")
+    return clone, func(args ...string) string { return run(clone, args...) }
+}
+
+func findBranch(t *testing.T, bs []Branch, name string) Branch {
+    t.Helper()
+    for _, b := range bs {
+        if b.Name == name { return b }
+    }
+    t.Fatalf("branch %s not listed in %+v", name, bs)
+    return Branch{}
+}
+
+func TestBranches(t *testing.T) {
+    repo, git := testRepo(t)
+    git("branch", "merged")
+    git("switch", "-q", "-c", "feat")
+    git("commit", "-q", "--allow-empty", "-m", "f1")
+    git("commit", "-q", "--allow-empty", "-m", "f2")
+    git("switch", "-q", "main")
+    git("commit", "-q", "--allow-empty", "-m", "m1")
+    bs, err := Branches(repo, "main")
+    if err != nil { t.Fatal(err) }
+    if b := findBranch(t, bs, "feat"); b.Ahead != 2 || b.Behind != 1 || b.Merged || b.Remote { t.Errorf("feat: %+v", b) }
+    if b := findBranch(t, bs, "merged"); b.Ahead != 0 || b.Behind != 1 || !b.Merged { t.Errorf("merged: %+v", b) }
+    if b := findBranch(t, bs, "main"); !b.Current || b.Upstream != "origin/main" || b.Ahead != 0 || b.Behind != 0 { t.Errorf("main: %+v", b) }
+    if b := findBranch(t, bs, "origin/main"); !b.Remote || b.Behind != 1 || !b.Merged { t.Errorf("origin/main: %+v", b) }
+    if bs[len(bs)-1].Name != "origin/main" { t.Errorf("remote branches come last: %+v", bs) }
+}
+
+func TestDeleteBranch(t *testing.T) {
+    repo, git := testRepo(t)
+    git("branch", "merged")
+    git("switch", "-q", "-c", "unmerged")
+    git("commit", "-q", "--allow-empty", "-m", "u1")
+    git("switch", "-q", "main")
+
+    if err := DeleteBranch(repo, "unmerged", "main"); err == nil || !strings.Contains(err.Error(), "has commits not in main") {
+        t.Errorf("unmerged: error %v, want a refusal", err)
+    }
+    if git("branch", "--list", "unmerged") == "" { t.Error("unmerged was deleted") }
+    if err := DeleteBranch(repo, "unmerged", ""); err == nil { t.Error("no base: want an error") }
+
+    if err := DeleteBranch(repo, "merged", "main"); err != nil { t.Errorf("merged: %v", err) }
+    if git("branch", "--list", "merged") != "" { t.Error("merged was kept") }
+
+    // merged into base but not into HEAD, which git's own -d check would refuse
+    git("switch", "-q", "-c", "side", "main")
+    git("commit", "-q", "--allow-empty", "-m", "s1")
+    git("branch", "onside")
+    if err := DeleteBranch(repo, "onside", "side"); err != nil { t.Errorf("merged into base: %v", err) }
+    git("switch", "-q", "main")
+    if err := DeleteBranch(repo, "side", "main"); err == nil { t.Error("side: want a refusal") }
+}
+
+func TestCheckoutRemote(t *testing.T) {
+    repo, git := testRepo(t)
+    git("switch", "-q", "-c", "feat")
+    git("commit", "-q", "--allow-empty", "-m", "f1")
+    git("push", "-q", "origin", "feat")
+    git("switch", "-q", "main")
+    git("branch", "-D", "feat")
+
+    if err := Checkout(repo, Branch{Name: "origin/feat", Remote: true}); err != nil { t.Fatal(err) }
+    if got := git("branch", "--show-current"); got != "feat" { t.Errorf("on %q, want feat", got) }
+    if got := git("rev-parse", "--abbrev-ref", "feat@{upstream}"); got != "origin/feat" { t.Errorf("upstream %q, want origin/feat", got) }
+
+    // with the local branch in place it is switched to, not recreated
+    git("switch", "-q", "main")
+    git("switch", "-q", "feat")
+    git("commit", "-q", "--allow-empty", "-m", "local work")
+    want := git("rev-parse", "feat")
+    git("switch", "-q", "main")
+    if err := Checkout(repo, Branch{Name: "origin/feat", Remote: true}); err != nil { t.Fatal(err) }
+    if got := git("branch", "--show-current"); got != "feat" { t.Errorf("on %q, want feat", got) }
+    if got := git("rev-parse", "HEAD"); got != want { t.Errorf("feat moved to %s, want %s", got, want) }
+
+    if err := Checkout(repo, Branch{Name: "main"}); err != nil { t.Fatal(err) }
+    if got := git("branch", "--show-current"); got != "main" { t.Errorf("on %q, want main", got) }
+}
@@ -1,6 +1,10 @@
 package gitutil
 
 import (
+    "context"
+    "errors"
+    "net/url"
+    "os"
     "os/exec"
     "strings"
 )
@@ -39,3 +43,72 @@ func normalizeURL(u string) string {
     return u
 }
 
+// PullRequestURL returns the page for opening a pull/merge request from
+// branch on the hosting site of remote (a RemoteURL), or "" for hosts it
+// doesn't know.
+func PullRequestURL(remote, branch string) string {
+    if branch == "" || !strings.HasPrefix(remote, "https://") { return "" }
+    esc := url.QueryEscape(branch)
+    switch {
+    case strings.Contains(remote, "github"):
+        segs := strings.Split(branch, "/")
+        for i, s := range segs { segs[i] = url.PathEscape(s) }
+        return remote + "/compare/" + strings.Join(segs, "/") + "?expand=1"
+    case strings.Contains(remote, "gitlab"):
+        return remote + "/-/merge_requests/new?merge_request%5Bsource_branch%5D=" + esc
+    case strings.Contains(remote, "bitbucket.org"):
+        return remote + "/pull-requests/new?source=" + esc
+    }
+    return ""
+}
+
+// Upstream returns the upstream of the current branch (e.g. "origin/main"),
+// or "" if none is configured.
+func Upstream(path string) string {
+    out, err := exec.Command("git", "-C", path, "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}").Output()
+    if err != nil { return "" }
+    return strings.TrimSpace(string(out))
+}
+
+// Command returns an unstarted git subcommand in path, set up for background
+// use: it never blocks on credential prompts.
+func Command(ctx context.Context, path string, args ...string) *exec.Cmd {
+    cmd := exec.CommandContext(ctx, "git", append([]string{"-C", path}, args...)...)
+    cmd.Env = append(cmd.Environ(), "GIT_TERMINAL_PROMPT=0")
+    return cmd
+}
+
+// Batch makes cmd fail instead of asking for credentials, for git run away
+// from the terminal: ssh runs in batch mode and never falls back to an
+// SSH_ASKPASS program. A GIT_SSH_COMMAND in the environment is kept and
+// given the option; a GIT_SSH program is left alone.
+func Batch(cmd *exec.Cmd) *exec.Cmd {
+    ssh := os.Getenv("GIT_SSH_COMMAND")
+    if ssh == "" && os.Getenv("GIT_SSH") != "" { return cmd }
+    if ssh == "" { ssh = "ssh" }
+    cmd.Env = append(cmd.Env, "GIT_SSH_COMMAND="+ssh+" -o BatchMode=yes", "SSH_ASKPASS_REQUIRE=never")
+    return cmd
+}
+
+// CommandError turns the result of a Command into an error carrying the
+// most relevant line of git's output, or nil on success.
+func CommandError(ctx context.Context, out []byte, err error) error {
+    if err == nil { return nil }
+    if ctx.Err() == context.DeadlineExceeded { return errors.New("timed out") }
+    if msg := errorLine(string(out)); msg != "" { return errors.New(msg) }
+    return err
+}
+
+// errorLine picks the first "fatal:"/"error:" line, else the first non-empty one.
+func errorLine(s string) string {
+    first := ""
+    for _, ln := range strings.Split(s, "\n") {
+        ln = strings.TrimSpace(ln)
+        if ln == "" { continue }
+        for _, pre := range []string{"fatal: ", "error: "} {
+            if strings.HasPrefix(ln, pre) { return strings.TrimPrefix(ln, pre) }
+        }
+        if first == "" { first = ln }
+    }
+    return first
+}
@@ -0,0 +1,66 @@
+package gitutil
+
+import (
+    "context"
+    "errors"
+    "os/exec"
+    "time"
+)
+
+// worktreeTimeout bounds the local git commands below.
+const worktreeTimeout = 30 * time.Second
+
+// Diff returns the plain diff of files in the repo at path: the staged
+// diff when cached is set, else the work tree against the index. Pass both
+// paths of a rename so it's shown as one. An untracked file is diffed
+// against /dev/null.
+func Diff(path string, cached, untracked bool, files ...string) (string, error) {
+    args := []string{"-c", "color.ui=never", "--no-pager", "diff", "--no-ext-diff"}
+    switch {
+    case untracked:
+        args = append(args, "--no-index", "--", "/dev/null")
+    case cached:
+        args = append(args, "--cached", "-M", "--")
+    default:
+        args = append(args, "--")
+    }
+    args = append(args, files...)
+    ctx, cancel := context.WithTimeout(context.Background(), worktreeTimeout)
+    defer cancel()
+    out, err := Command(ctx, path, args...).Output()
+    var ee *exec.ExitError
+    if !errors.As(err, &ee) { return string(out), CommandError(ctx, nil, err) }
+    // --no-index exits 1 when the files differ, but also on some errors
+    if untracked && ee.ExitCode() == 1 && len(out) > 0 && len(ee.Stderr) == 0 { return string(out), nil }
+    return "", CommandError(ctx, ee.Stderr, err)
+}
+
+// Stage adds file (or its deletion) to the index.
+func Stage(path, file string) error { return run(path, "add", "-A", "--", file) }
+
+// Unstage resets the index entries of files to HEAD; pass both paths of
+// a rename.
+func Unstage(path string, files ...string) error {
+    return run(path, append([]string{"restore", "--staged", "--"}, files...)...)
+}
+
+// Discard throws away every change to file: an untracked file is deleted, a
+// file new in the index is removed, others are restored from HEAD. For a
+// rename, orig is restored and file removed.
+func Discard(path, file, orig string, untracked, added bool) error {
+    switch {
+    case untracked:
+        return run(path, "clean", "-f", "-q", "--", file)
+    case added || orig != "":
+        if err := run(path, "rm", "-f", "-q", "--", file); err != nil || orig == "" { return err }
+        file = orig
+    }
+    return run(path, "restore", "--source=HEAD", "--staged", "--worktree", "--", file)
+}
+
+func run(path string, args ...string) error {
+    ctx, cancel := context.WithTimeout(context.Background(), worktreeTimeout)
+    defer cancel()
+    out, err := Command(ctx, path, args...).CombinedOutput()
+    return CommandError(ctx, out, err)
+}
@@ -0,0 +1,193 @@
+// Package mux opens repos in terminal multiplexers (tmux, zellij): one named
+// session per repo, reused while it is alive, or a window/pane in the
+// session the TUI itself runs in.
+package mux
+
+import (
+    "fmt"
+    "hash/fnv"
+    "os"
+    "os/exec"
+    "path/filepath"
+    "regexp"
+    "strings"
+)
+
+type Kind string
+
+const (
+    Tmux   Kind = "tmux"
+    Zellij Kind = "zellij"
+)
+
+// Targets for Open when the TUI runs inside the multiplexer.
+const (
+    TargetSession = "session" // per-repo session (default)
+    TargetWindow  = "window"  // new window/tab in the current session
+    TargetPane    = "pane"    // new pane in the current window
+)
+
+// Mux is a detected multiplexer.
+type Mux struct {
+    Kind   Kind
+    Inside bool // the TUI runs inside it
+}
+
+// Inside returns the multiplexer the TUI runs in, if any.
+func Inside() (Mux, bool) {
+    switch {
+    case os.Getenv("TMUX") != "":
+        return Mux{Kind: Tmux, Inside: true}, true
+    case os.Getenv("ZELLIJ") != "":
+        return Mux{Kind: Zellij, Inside: true}, true
+    }
+    return Mux{}, false
+}
+
+// Detect resolves a backend preference: "tmux", "zellij" or "auto" (the one
+// the TUI runs in, else the first installed).
+func Detect(pref string) (Mux, bool) {
+    in, inside := Inside()
+    switch Kind(pref) {
+    case Tmux, Zellij:
+        if !installed(Kind(pref)) { return Mux{}, false }
+        return Mux{Kind: Kind(pref), Inside: inside && in.Kind == Kind(pref)}, true
+    }
+    if inside { return in, true }
+    for _, k := range []Kind{Tmux, Zellij} {
+        if installed(k) { return Mux{Kind: k}, true }
+    }
+    return Mux{}, false
+}
+
+func installed(k Kind) bool {
+    _, err := exec.LookPath(string(k))
+    return err == nil
+}
+
+var unsafeName = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// SessionName is the session used for the repo at path: its base name plus a
+// short hash of the full path, so equally named repos don't collide.
+func SessionName(path string) string {
+    h := fnv.New32a()
+    h.Write([]byte(path))
+    base := unsafeName.ReplaceAllString(filepath.Base(path), "_")
+    return fmt.Sprintf("%s-%04x", base, h.Sum32()&0xffff)
+}
+
+// Sessions are the live sessions of a multiplexer.
+type Sessions struct {
+    names map[string]bool
+    paths map[string]string // start directory -> session name (tmux only)
+}
+
+// Has reports whether the repo at path has a live session.
+func (s Sessions) Has(path string) bool {
+    _, ok := s.lookup(path)
+    return ok
+}
+
+func (s Sessions) lookup(path string) (string, bool) {
+    if name := SessionName(path); s.names[name] { return name, true }
+    name, ok := s.paths[path]
+    return name, ok
+}
+
+// List returns the live sessions.
+func (m Mux) List() Sessions {
+    s := Sessions{names: map[string]bool{}, paths: map[string]string{}}
+    switch m.Kind {
+    case Tmux:
+        out, err := exec.Command("tmux", "list-sessions", "-F", "#{session_name}\t#{session_path}").Output()
+        if err != nil { return s } // no server running
+        for _, ln := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+            name, path, _ := strings.Cut(ln, "\t")
+            if name == "" { continue }
+            s.names[name] = true
+            if path != "" { s.paths[path] = name }
+        }
+    case Zellij:
+        out, err := exec.Command("zellij", "list-sessions", "--short", "--no-formatting").Output()
+        if err != nil { return s }
+        for _, name := range strings.Fields(string(out)) { s.names[name] = true }
+    }
+    return s
+}
+
+// Plan is what opening a repo takes: quick setup commands run in order, an
+// optional command bringing the session to the front, and an optional
+// interactive command that needs the terminal (attaching from outside the
+// multiplexer). Bulk opens run only Setup.
+type Plan struct {
+    Setup  []*exec.Cmd
+    Focus  *exec.Cmd
+    Attach *exec.Cmd
+}
+
+// Open plans opening the repo at path, running shellCmd (bash -lc) or a shell
+// when it is empty. name labels new windows and panes.
+func (m Mux) Open(path, name, shellCmd, target string) Plan {
+    var argv []string
+    if shellCmd != "" { argv = []string{"bash", "-lc", shellCmd} }
+    if m.Kind == Zellij { return m.openZellij(path, name, argv, target) }
+    return m.openTmux(path, name, argv, target)
+}
+
+func (m Mux) openTmux(path, name string, argv []string, target string) Plan {
+    var p Plan
+    if m.Inside && target == TargetWindow {
+        p.Setup = append(p.Setup, tmux(append([]string{"new-window", "-c", path, "-n", name}, argv...)...))
+        return p
+    }
+    if m.Inside && target == TargetPane {
+        p.Setup = append(p.Setup, tmux(append([]string{"split-window", "-c", path}, argv...)...))
+        return p
+    }
+    sess, live := m.List().lookup(path)
+    if !live {
+        sess = SessionName(path)
+        p.Setup = append(p.Setup, tmux(append([]string{"new-session", "-d", "-s", sess, "-c", path, "-n", name}, argv...)...))
+    } else if len(argv) > 0 {
+        p.Setup = append(p.Setup, tmux(append([]string{"new-window", "-t", "=" + sess + ":", "-c", path, "-n", name}, argv...)...))
+    }
+    // "=" makes tmux match the session name exactly rather than as a prefix
+    if m.Inside {
+        p.Focus = tmux("switch-client", "-t", "="+sess)
+    } else {
+        p.Attach = tmux("attach-session", "-t", "="+sess)
+    }
+    return p
+}
+
+func (m Mux) openZellij(path, name string, argv []string, target string) Plan {
+    var p Plan
+    // zellij can't switch the client to another session from the CLI, so
+    // inside it a session target opens a tab instead
+    if m.Inside {
+        if target != TargetPane {
+            p.Setup = append(p.Setup, zellij("action", "new-tab", "--cwd", path, "--name", name))
+        }
+        if len(argv) > 0 {
+            p.Setup = append(p.Setup, zellij(append([]string{"run", "--cwd", path, "--name", name, "--"}, argv...)...))
+        } else if target == TargetPane {
+            p.Setup = append(p.Setup, zellij("action", "new-pane", "--cwd", path))
+        }
+        return p
+    }
+    sess := SessionName(path)
+    if _, live := m.List().lookup(path); !live {
+        c := zellij("attach", "--create-background", sess)
+        c.Dir = path
+        p.Setup = append(p.Setup, c)
+    }
+    if len(argv) > 0 {
+        p.Setup = append(p.Setup, zellij(append([]string{"--session", sess, "run", "--cwd", path, "--name", name, "--"}, argv...)...))
+    }
+    p.Attach = zellij("attach", sess)
+    p.Attach.Dir = path
+    return p
+}
+
+func tmux(args ...string) *exec.Cmd   { return exec.Command("tmux", args...) }
+func zellij(args ...string) *exec.Cmd { return exec.Command("zellij", args...) }
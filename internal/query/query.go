@@ -0,0 +1,439 @@
+// Package query implements the small filter language used by the "/" filter
+// and the headless commands.
+//
+// A query is a list of terms combined with AND (implicit, "and", "&") and OR
+// ("or", "|"). Terms can be negated ("not", "!", "-") and grouped with
+// parentheses. AND binds tighter than OR.
+//
+// Terms:
+//
+//   dirty clean conflicts ahead behind detached pkg mono cached
+//   staged unstaged untracked stashed no-upstream in-progress unpushed
+//   ahead>0 behind>=2 conflicts=0 stashes>1 unpushed>1   (ops: = != > >= < <=)
+//   branch:feat/*  name:api  path:~/work/*  state:stale  tag:archived
+//   upstream:origin/*  op:rebase
+//   anything else                     substring of the name or branch, or (3+ characters)
+//                                     a fuzzy match on the name
+//
+// In branch:, name:, path:, tag:, upstream: and op: values "*" matches any
+// run of characters (including "/") and "?" one character. Without wildcards
+// they compare exactly, except path:, which matches the directory and
+// everything below it.
+package query
+
+import (
+    "fmt"
+    "path/filepath"
+    "regexp"
+    "sort"
+    "strconv"
+    "strings"
+    "unicode/utf8"
+
+    "github.com/sahilm/fuzzy"
+
+    "workflow/internal/config"
+    "workflow/internal/scanner"
+)
+
+// Subject is what a query is evaluated against.
+type Subject struct {
+    Repo scanner.RepoEntry
+    Name string   // display name; falls back to Repo.Name
+    Tags []string
+}
+
+// NewSubject builds a subject for r using the display name and tags from cfg.
+func NewSubject(cfg config.Config, r scanner.RepoEntry, name string) Subject {
+    if name == "" {
+        name = r.Name
+        if r.WorkspacePkg && r.PackageName != "" { name = r.PackageName }
+    }
+    return Subject{Repo: r, Name: name, Tags: cfg.Tags(r.Path)}
+}
+
+// Expr is a compiled query.
+type Expr interface {
+    Match(s Subject) bool
+}
+
+type matchFunc func(s Subject) bool
+
+func (f matchFunc) Match(s Subject) bool { return f(s) }
+
+var matchAll = matchFunc(func(Subject) bool { return true })
+
+// Error is a parse error with the byte offset of the offending token.
+type Error struct {
+    Pos int
+    Msg string
+}
+
+func (e *Error) Error() string { return fmt.Sprintf("%s (at %d)", e.Msg, e.Pos+1) }
+
+// Query is a compiled filter. A nil *Query matches everything.
+type Query struct {
+    expr  Expr
+    words []string // free-text terms outside negations, used for ranking
+}
+
+// Match reports whether s satisfies the query.
+func (q *Query) Match(s Subject) bool {
+    if q == nil || q.expr == nil { return true }
+    return q.expr.Match(s)
+}
+
+// Ranked reports whether the query has free text that results can be ranked by.
+func (q *Query) Ranked() bool { return q != nil && len(q.words) > 0 }
+
+// Score ranks name against the free-text terms using fuzzy matching. idx holds
+// the byte offsets of matched characters in name, for highlighting.
+func (q *Query) Score(name string) (score int, idx []int) {
+    if !q.Ranked() { return 0, nil }
+    seen := map[int]bool{}
+    for _, w := range q.words {
+        sc, mi, ok := matchTerm(w, name)
+        if !ok { continue }
+        score += sc
+        for _, i := range mi {
+            if !seen[i] { seen[i] = true; idx = append(idx, i) }
+        }
+    }
+    sort.Ints(idx)
+    return score, idx
+}
+
+const (
+    // minFuzzyLen is the shortest term matched fuzzily; shorter ones only
+    // match as a substring.
+    minFuzzyLen = 3
+    // minFuzzyScore drops fuzzy matches scattered across the name.
+    minFuzzyScore = 0
+    // substringScore ranks substring matches above fuzzy ones.
+    substringScore = 100
+)
+
+// matchTerm matches a free-text term against name: as a case-insensitive
+// substring, or fuzzily for longer terms. idx holds the byte offsets of the
+// matched characters.
+func matchTerm(w, name string) (score int, idx []int, ok bool) {
+    if idx := substringIdx(name, w); idx != nil { return substringScore - idx[0], idx, true }
+    if utf8.RuneCountInString(w) < minFuzzyLen { return 0, nil, false }
+    ms := fuzzy.Find(w, []string{name})
+    if len(ms) == 0 || ms[0].Score < minFuzzyScore { return 0, nil, false }
+    return ms[0].Score, ms[0].MatchedIndexes, true
+}
+
+// substringIdx returns the byte offsets of the runes of the first
+// case-insensitive occurrence of w in s, or nil.
+func substringIdx(s, w string) []int {
+    n := utf8.RuneCountInString(w)
+    if n == 0 { return nil }
+    var offs []int
+    for i := range s { offs = append(offs, i) }
+    for i := 0; i+n <= len(offs); i++ {
+        end := len(s)
+        if i+n < len(offs) { end = offs[i+n] }
+        if strings.EqualFold(s[offs[i]:end], w) { return append([]int(nil), offs[i:i+n]...) }
+    }
+    return nil
+}
+
+// Parse compiles src. An empty query matches everything.
+func Parse(src string) (*Query, error) {
+    toks, err := lex(src)
+    if err != nil { return nil, err }
+    if len(toks) == 0 { return &Query{expr: matchAll}, nil }
+    p := &parser{toks: toks}
+    e, err := p.parseOr()
+    if err != nil { return nil, err }
+    if p.pos < len(p.toks) {
+        t := p.toks[p.pos]
+        return nil, &Error{Pos: t.pos, Msg: fmt.Sprintf("unexpected %q", t.text)}
+    }
+    return &Query{expr: e, words: p.words}, nil
+}
+
+type tokKind int
+
+const (
+    tokWord tokKind = iota
+    tokLParen
+    tokRParen
+    tokNot
+    tokAnd
+    tokOr
+)
+
+type token struct {
+    kind tokKind
+    text string
+    pos  int
+}
+
+func lex(src string) ([]token, error) {
+    var toks []token
+    i := 0
+    for i < len(src) {
+        c := src[i]
+        switch {
+        case c == ' ' || c == '\t':
+            i++
+        case c == '(':
+            toks = append(toks, token{tokLParen, "(", i})
+            i++
+        case c == ')':
+            toks = append(toks, token{tokRParen, ")", i})
+            i++
+        case c == '!' || (c == '-' && i+1 < len(src) && src[i+1] != ' '):
+            toks = append(toks, token{tokNot, string(c), i})
+            i++
+        case c == '|' || c == '&':
+            start := i
+            for i < len(src) && src[i] == c { i++ }
+            k := tokOr
+            if c == '&' { k = tokAnd }
+            toks = append(toks, token{k, src[start:i], start})
+        default:
+            start := i
+            var sb strings.Builder
+            for i < len(src) {
+                c := src[i]
+                if c == ' ' || c == '\t' || c == '(' || c == ')' || c == '|' || c == '&' { break }
+                if c == '"' {
+                    end := strings.IndexByte(src[i+1:], '"')
+                    if end < 0 { return nil, &Error{Pos: i, Msg: "unterminated quote"} }
+                    sb.WriteString(src[i+1 : i+1+end])
+                    i += end + 2
+                    continue
+                }
+                sb.WriteByte(c)
+                i++
+            }
+            w := sb.String()
+            raw := src[start:i]
+            switch strings.ToLower(raw) {
+            case "and":
+                toks = append(toks, token{tokAnd, raw, start})
+            case "or":
+                toks = append(toks, token{tokOr, raw, start})
+            case "not":
+                toks = append(toks, token{tokNot, raw, start})
+            default:
+                toks = append(toks, token{tokWord, w, start})
+            }
+        }
+    }
+    return toks, nil
+}
+
+type parser struct {
+    toks  []token
+    pos   int
+    neg   int      // negation depth at the current term
+    words []string // free-text terms seen outside negations
+}
+
+func (p *parser) peek() (token, bool) {
+    if p.pos >= len(p.toks) { return token{}, false }
+    return p.toks[p.pos], true
+}
+
+func (p *parser) parseOr() (Expr, error) {
+    left, err := p.parseAnd()
+    if err != nil { return nil, err }
+    for {
+        t, ok := p.peek()
+        if !ok || t.kind != tokOr { return left, nil }
+        p.pos++
+        right, err := p.parseAnd()
+        if err != nil { return nil, err }
+        l, r := left, right
+        left = matchFunc(func(s Subject) bool { return l.Match(s) || r.Match(s) })
+    }
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+    left, err := p.parseUnary()
+    if err != nil { return nil, err }
+    for {
+        t, ok := p.peek()
+        if !ok || t.kind == tokOr || t.kind == tokRParen { return left, nil }
+        if t.kind == tokAnd { p.pos++ }
+        right, err := p.parseUnary()
+        if err != nil { return nil, err }
+        l, r := left, right
+        left = matchFunc(func(s Subject) bool { return l.Match(s) && r.Match(s) })
+    }
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+    t, ok := p.peek()
+    if !ok {
+        end := 0
+        if n := len(p.toks); n > 0 { end = p.toks[n-1].pos + len(p.toks[n-1].text) }
+        return nil, &Error{Pos: end, Msg: "expected a term"}
+    }
+    switch t.kind {
+    case tokNot:
+        p.pos++
+        p.neg++
+        e, err := p.parseUnary()
+        p.neg--
+        if err != nil { return nil, err }
+        return matchFunc(func(s Subject) bool { return !e.Match(s) }), nil
+    case tokLParen:
+        p.pos++
+        e, err := p.parseOr()
+        if err != nil { return nil, err }
+        if c, ok := p.peek(); !ok || c.kind != tokRParen {
+            return nil, &Error{Pos: t.pos, Msg: "missing )"}
+        }
+        p.pos++
+        return e, nil
+    case tokWord:
+        p.pos++
+        return p.parseTerm(t)
+    default:
+        return nil, &Error{Pos: t.pos, Msg: fmt.Sprintf("unexpected %q", t.text)}
+    }
+}
+
+var cmpRe = regexp.MustCompile(`^([a-z]+)(>=|<=|!=|=|>|<)(-?\d+)$`)
+
+// numeric fields usable in comparisons
+var numFields = map[string]func(r scanner.RepoEntry) int{
+    "ahead":     func(r scanner.RepoEntry) int { return r.Ahead },
+    "behind":    func(r scanner.RepoEntry) int { return r.Behind },
+    "conflicts": func(r scanner.RepoEntry) int { return r.Conflicts },
+    "staged":    func(r scanner.RepoEntry) int { return r.Staged },
+    "unstaged":  func(r scanner.RepoEntry) int { return r.Unstaged },
+    "untracked": func(r scanner.RepoEntry) int { return r.Untracked },
+    "stashes":   func(r scanner.RepoEntry) int { return r.Stashes },
+    "unpushed":  func(r scanner.RepoEntry) int { return len(r.Unpushed) },
+}
+
+// flag terms
+var flags = map[string]func(r scanner.RepoEntry) bool{
+    "dirty":       func(r scanner.RepoEntry) bool { return r.Dirty },
+    "clean":       func(r scanner.RepoEntry) bool { return !r.Dirty },
+    "conflicts":   func(r scanner.RepoEntry) bool { return r.Conflicts > 0 },
+    "ahead":       func(r scanner.RepoEntry) bool { return r.Ahead > 0 },
+    "behind":      func(r scanner.RepoEntry) bool { return r.Behind > 0 },
+    "detached":    func(r scanner.RepoEntry) bool { return r.Detached },
+    "pkg":         func(r scanner.RepoEntry) bool { return r.WorkspacePkg },
+    "mono":        func(r scanner.RepoEntry) bool { return r.Monorepo },
+    "cached":      func(r scanner.RepoEntry) bool { return r.Stale },
+    "staged":      func(r scanner.RepoEntry) bool { return r.Staged > 0 },
+    "unstaged":    func(r scanner.RepoEntry) bool { return r.Unstaged > 0 },
+    "untracked":   func(r scanner.RepoEntry) bool { return r.Untracked > 0 },
+    "stashed":     func(r scanner.RepoEntry) bool { return r.Stashes > 0 },
+    "no-upstream": func(r scanner.RepoEntry) bool { return r.NoUpstream },
+    "in-progress": func(r scanner.RepoEntry) bool { return r.Operation != "" },
+    "unpushed":    func(r scanner.RepoEntry) bool { return len(r.Unpushed) > 0 },
+}
+
+func (p *parser) parseTerm(t token) (Expr, error) {
+    w := t.text
+    lw := strings.ToLower(w)
+    if m := cmpRe.FindStringSubmatch(lw); m != nil {
+        get, ok := numFields[m[1]]
+        if !ok { return nil, &Error{Pos: t.pos, Msg: fmt.Sprintf("unknown numeric field %q", m[1])} }
+        n, err := strconv.Atoi(m[3])
+        if err != nil { return nil, &Error{Pos: t.pos, Msg: "bad number " + m[3]} }
+        cmp := compare(m[2], n)
+        return matchFunc(func(s Subject) bool { return cmp(get(s.Repo)) }), nil
+    }
+    if f, ok := flags[lw]; ok {
+        return matchFunc(func(s Subject) bool { return f(s.Repo) }), nil
+    }
+    if i := strings.IndexByte(w, ':'); i > 0 {
+        key, val := strings.ToLower(w[:i]), w[i+1:]
+        if val == "" { return nil, &Error{Pos: t.pos, Msg: fmt.Sprintf("missing value for %s:", key)} }
+        switch key {
+        case "branch":
+            return matchFunc(func(s Subject) bool { return matchText(val, s.Repo.Branch) }), nil
+        case "name":
+            return matchFunc(func(s Subject) bool { return matchText(val, s.Name) }), nil
+        case "upstream":
+            return matchFunc(func(s Subject) bool { return matchText(val, s.Repo.Upstream) }), nil
+        case "op":
+            return matchFunc(func(s Subject) bool { return matchText(val, s.Repo.Operation) }), nil
+        case "state":
+            return matchFunc(func(s Subject) bool { return strings.EqualFold(val, scanner.Bucket(s.Repo.LastAge)) }), nil
+        case "tag":
+            return matchFunc(func(s Subject) bool {
+                for _, tg := range s.Tags {
+                    if matchText(val, tg) { return true }
+                }
+                return false
+            }), nil
+        case "path":
+            pat := filepath.Clean(config.ExpandUser(val))
+            return matchFunc(func(s Subject) bool { return matchPath(pat, s.Repo.Path) }), nil
+        default:
+            return nil, &Error{Pos: t.pos, Msg: fmt.Sprintf("unknown field %q", key)}
+        }
+    }
+    if p.neg%2 == 0 { p.words = append(p.words, w) }
+    return matchFunc(func(s Subject) bool {
+        if strings.Contains(strings.ToLower(s.Repo.Branch), lw) { return true }
+        _, _, ok := matchTerm(w, s.Name)
+        return ok
+    }), nil
+}
+
+func compare(op string, n int) func(int) bool {
+    switch op {
+    case "=":
+        return func(v int) bool { return v == n }
+    case "!=":
+        return func(v int) bool { return v != n }
+    case ">":
+        return func(v int) bool { return v > n }
+    case ">=":
+        return func(v int) bool { return v >= n }
+    case "<":
+        return func(v int) bool { return v < n }
+    default:
+        return func(v int) bool { return v <= n }
+    }
+}
+
+// matchText compares case-insensitively, with wildcards when present.
+func matchText(pat, s string) bool {
+    if !strings.ContainsAny(pat, "*?") { return strings.EqualFold(pat, s) }
+    return glob(strings.ToLower(pat), strings.ToLower(s))
+}
+
+// matchPath matches a path glob, or a directory prefix when pat has no wildcards.
+func matchPath(pat, p string) bool {
+    if !strings.ContainsAny(pat, "*?") {
+        return p == pat || strings.HasPrefix(p, pat+string(filepath.Separator))
+    }
+    return glob(pat, p)
+}
+
+// glob matches s against a pattern where '*' spans any characters and '?'
+// matches exactly one.
+func glob(pat, s string) bool {
+    px, sx := 0, 0
+    starP, starS := -1, 0
+    for sx < len(s) {
+        if px < len(pat) && (pat[px] == '?' || pat[px] == s[sx]) {
+            px++
+            sx++
+        } else if px < len(pat) && pat[px] == '*' {
+            starP, starS = px, sx
+            px++
+        } else if starP >= 0 {
+            px = starP + 1
+            starS++
+            sx = starS
+        } else {
+            return false
+        }
+    }
+    for px < len(pat) && pat[px] == '*' { px++ }
+    return px == len(pat)
+}
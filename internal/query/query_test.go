@@ -0,0 +1,117 @@
+package query
+
+import (
+    "testing"
+
+    "workflow/internal/scanner"
+)
+
+// repos the eval tests run against, by name.
+var repos = []Subject{
+    {Name: "workflow", Repo: scanner.RepoEntry{Path: "/w/workflow", Branch: "main", Dirty: true, Ahead: 2, Staged: 1, LastAge: "now"}},
+    {Name: "dotfiles", Repo: scanner.RepoEntry{Path: "/w/dotfiles", Branch: "master", LastAge: "20d"}},
+    {Name: "api", Repo: scanner.RepoEntry{Path: "/w/svc/api", Branch: "feat/login", Behind: 3, Upstream: "origin/feat/login", LastAge: "3mo"}, Tags: []string{"work"}},
+    {Name: "web", Repo: scanner.RepoEntry{Path: "/w/svc/web", Branch: "main", Dirty: true, Conflicts: 1, Operation: "rebase", LastAge: "1mo"}, Tags: []string{"work", "archived"}},
+}
+
+func TestEval(t *testing.T) {
+    tests := []struct {
+        q    string
+        want string // names of the matching repos, in order
+    }{
+        {"", "workflow dotfiles api web"},
+        {"dirty", "workflow web"},
+        {"clean", "dotfiles api"},
+        {"DIRTY", "workflow web"},
+        {"ahead>0", "workflow"},
+        {"behind>=3", "api"},
+        {"behind<3", "workflow dotfiles web"},
+        {"conflicts=0", "workflow dotfiles api"},
+        {"staged!=0", "workflow"},
+        {"branch:main", "workflow web"},
+        {"branch:feat/*", "api"},
+        {"upstream:origin/*", "api"},
+        {"op:rebase", "web"},
+        {"in-progress", "web"},
+        {"tag:work", "api web"},
+        {"path:/w/svc", "api web"},
+        {"path:/w/sv", ""},
+        {"state:active", "workflow"},
+        {"state:stale", "dotfiles web"},
+        {"state:dormant", "api"},
+        {`name:"web"`, "web"},
+        // negation
+        {"not dirty", "dotfiles api"},
+        {"-dirty", "dotfiles api"},
+        {"!dirty", "dotfiles api"},
+        {"not not dirty", "workflow web"},
+        {"-tag:archived", "workflow dotfiles api"},
+        {"tag:work -tag:archived", "api"},
+        // precedence: AND binds tighter than OR
+        {"dirty or behind>0 conflicts>0", "workflow web"},
+        {"dirty and ahead>0 or behind>0", "workflow api"},
+        {"(dirty or behind>0) conflicts=0", "workflow api"},
+        {"dirty | clean & behind>0", "workflow api web"},
+        {"dirty && web", "web"},
+        {"not (dirty or tag:work)", "dotfiles"},
+        // free text: substrings, longer terms fuzzily, and the branch
+        {"dot", "dotfiles"},
+        {"wf", ""},
+        {"wrkf", "workflow"},
+        {"login", "api"},
+        {"w", "workflow web"},
+    }
+    for _, tt := range tests {
+        q, err := Parse(tt.q)
+        if err != nil {
+            t.Errorf("Parse(%q): %v", tt.q, err)
+            continue
+        }
+        got := ""
+        for _, s := range repos {
+            if !q.Match(s) { continue }
+            if got != "" { got += " " }
+            got += s.Name
+        }
+        if got != tt.want { t.Errorf("%q matched %q, want %q", tt.q, got, tt.want) }
+    }
+}
+
+func TestParseErrors(t *testing.T) {
+    tests := []struct {
+        q   string
+        pos int // 1-based, as in the message
+    }{
+        {"(dirty", 1},
+        {"dirty)", 6},
+        {"dirty or", 9},
+        {"not", 4},
+        {"and dirty", 1},
+        {"()", 2},
+        {`name:"web`, 6},
+        {"branch:", 1},
+        {"color:red", 1},
+        {"commits>1", 1},
+        {"dirty | | clean", 9},
+    }
+    for _, tt := range tests {
+        _, err := Parse(tt.q)
+        e, ok := err.(*Error)
+        if !ok {
+            t.Errorf("Parse(%q) = %v, want a parse error", tt.q, err)
+            continue
+        }
+        if e.Pos+1 != tt.pos { t.Errorf("Parse(%q): error %q at %d, want %d", tt.q, e.Msg, e.Pos+1, tt.pos) }
+    }
+}
+
+func TestScore(t *testing.T) {
+    q, err := Parse("flow -dirty")
+    if err != nil { t.Fatal(err) }
+    if !q.Ranked() { t.Fatal("free text should rank") }
+    sc, idx := q.Score("workflow")
+    if sc <= 0 || len(idx) != 4 || idx[0] != 4 { t.Errorf("Score(workflow) = %d, %v", sc, idx) }
+    // a substring ranks above a scattered match
+    if a, _ := q.Score("flowers"); a <= sc { t.Errorf("prefix match scored %d, not above %d", a, sc) }
+    if q, _ := Parse("-flow"); q.Ranked() { t.Error("negated text shouldn't rank") }
+}
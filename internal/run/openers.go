@@ -8,7 +8,8 @@ import (
     "workflow/internal/config"
 )
 
-func OpenGUIEditor(cwd string, cfg config.Config) error {
+// GUIEditorCmd opens cwd in the first available GUI editor.
+func GUIEditorCmd(cwd string, cfg config.Config) (*exec.Cmd, error) {
     editors := append([]string{}, cfg.Editor.GUIFallbacks...)
     // try preferred GUI first if set explicitly in default and looks like GUI
     if cfg.Editor.Default == "cursor" || cfg.Editor.Default == "code" {
@@ -21,10 +22,9 @@ func OpenGUIEditor(cwd string, cfg config.Config) error {
         seen[e] = struct{}{}
         if _, err := exec.LookPath(e); err == nil {
             // cursor/code both accept a path argument
-            cmd := exec.Command(e, cwd)
-            return cmd.Start()
+            return exec.Command(e, cwd), nil
         }
     }
-    return fmt.Errorf("no GUI editor found (tried: %s)", strings.Join(editors, ", "))
+    return nil, fmt.Errorf("no GUI editor found (tried: %s)", strings.Join(editors, ", "))
 }
 
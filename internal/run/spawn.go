@@ -1,48 +1,52 @@
 package run
 
 import (
-    "fmt"
+    "os"
     "os/exec"
-    "strings"
 
     "workflow/internal/agents"
     "workflow/internal/config"
 )
 
-func OpenTerminalNewWindow(cwd string, cfg config.Config) error {
-    term := cfg.Terminal.Prefer
-    switch term {
-    case "alacritty", "":
-        if !agents.HasBinary("alacritty") {
-            return fmt.Errorf("alacritty not found")
-        }
-        // Let Alacritty start the user's default shell; no -e override
-        cmd := exec.Command("alacritty", "--working-directory", cwd)
-        return cmd.Start()
-    default:
-        return fmt.Errorf("unsupported terminal: %s", term)
-    }
+// The builders below return unstarted commands so callers can track the
+// process (PID, exit status, output) before starting it.
+
+// TerminalCmd opens a new terminal window in cwd running the user's shell.
+func TerminalCmd(cwd string, cfg config.Config) (*exec.Cmd, error) {
+    return windowCmd(cwd, "", cfg)
+}
+
+// AgentCmd launches agent in a new terminal window in cwd.
+func AgentCmd(cwd, agent string, cfg config.Config) (*exec.Cmd, error) {
+    return windowCmd(cwd, agents.BuildAgentCommand(agent, cwd, cfg), cfg)
+}
+
+// ShellCmd runs shellCmd in a new terminal window in cwd.
+func ShellCmd(cwd, shellCmd string, cfg config.Config) (*exec.Cmd, error) {
+    return windowCmd(cwd, shellCmd, cfg)
+}
+
+// InPlaceCmd runs shellCmd in cwd in the current terminal (the caller
+// suspends the TUI around it).
+func InPlaceCmd(cwd, shellCmd string) *exec.Cmd {
+    cmd := exec.Command("bash", "-lc", shellCmd)
+    cmd.Dir = cwd
+    return cmd
 }
 
-func LaunchAgentNewWindow(cwd, agent string, cfg config.Config) error {
-    if !agents.HasBinary("alacritty") {
-        return fmt.Errorf("alacritty not found")
-    }
-    sh := agents.BuildAgentCommand(agent, cwd, cfg)
-    // Ensure quotes are escaped for bash -lc
-    sh = strings.ReplaceAll(sh, "\"", "\\\"")
-    args := agents.BuildAlacrittyArgs(cwd, sh)
-    cmd := exec.Command("alacritty", args...)
-    return cmd.Start()
+// InPlaceShell starts the configured interactive shell in cwd.
+func InPlaceShell(cwd string, cfg config.Config) *exec.Cmd {
+    sh := cfg.Terminal.InPlaceShell
+    if sh == "" { sh = os.Getenv("SHELL") }
+    if sh == "" { sh = "sh" }
+    cmd := exec.Command(sh)
+    cmd.Dir = cwd
+    return cmd
 }
 
-func LaunchShellCmdNewWindow(cwd, shellCmd string, cfg config.Config) error {
-    if !agents.HasBinary("alacritty") {
-        return fmt.Errorf("alacritty not found")
-    }
-    // Escape quotes for bash -lc
-    shellCmd = strings.ReplaceAll(shellCmd, "\"", "\\\"")
-    args := agents.BuildAlacrittyArgs(cwd, shellCmd)
-    cmd := exec.Command("alacritty", args...)
-    return cmd.Start()
+// Headless reports whether new GUI windows can't be opened: an SSH session
+// or no Wayland/X11 display.
+func Headless() bool {
+    if os.Getenv("SSH_CONNECTION") != "" || os.Getenv("SSH_TTY") != "" { return true }
+    return os.Getenv("WAYLAND_DISPLAY") == "" && os.Getenv("DISPLAY") == ""
 }
@@ -0,0 +1,128 @@
+package run
+
+import (
+    "fmt"
+    "os"
+    "os/exec"
+    "strings"
+
+    "workflow/internal/agents"
+    "workflow/internal/config"
+)
+
+// Profile describes how to start a terminal emulator in a directory and how
+// to hand it a command. "{cwd}" in an argument is replaced by the directory.
+type Profile struct {
+    Bin  string
+    Pre  []string // subcommand, e.g. wezterm's "start"
+    Cwd  []string // working-directory flag; nil if unsupported (the process cwd is used)
+    Exec []string // placed before the command argv; empty when it is taken as trailing args
+}
+
+// profiles are the built-in terminals, tried in this order for "auto".
+var profiles = []Profile{
+    {Bin: "alacritty", Cwd: []string{"--working-directory", "{cwd}"}, Exec: []string{"-e"}},
+    {Bin: "kitty", Cwd: []string{"--directory", "{cwd}"}},
+    {Bin: "foot", Cwd: []string{"--working-directory={cwd}"}},
+    {Bin: "wezterm", Pre: []string{"start"}, Cwd: []string{"--cwd", "{cwd}"}, Exec: []string{"--"}},
+    {Bin: "ghostty", Cwd: []string{"--working-directory={cwd}"}, Exec: []string{"-e"}},
+    {Bin: "gnome-terminal", Cwd: []string{"--working-directory={cwd}"}, Exec: []string{"--"}},
+    {Bin: "konsole", Cwd: []string{"--workdir", "{cwd}"}, Exec: []string{"-e"}},
+    {Bin: "xterm", Exec: []string{"-e"}},
+}
+
+// lookupProfile returns the built-in profile for a terminal binary name.
+func lookupProfile(name string) (Profile, bool) {
+    for _, p := range profiles {
+        if p.Bin == name { return p, true }
+    }
+    return Profile{}, false
+}
+
+// resolveProfile picks the configured terminal, or the first installed one
+// for "auto".
+func resolveProfile(cfg config.Config) (Profile, error) {
+    term := cfg.Terminal.Prefer
+    if term == "" || term == "auto" {
+        for _, p := range profiles {
+            if agents.HasBinary(p.Bin) { return p, nil }
+        }
+        return Profile{}, fmt.Errorf("no terminal found (tried %s)", profileNames())
+    }
+    p, ok := lookupProfile(term)
+    if !ok {
+        return Profile{}, fmt.Errorf("unsupported terminal %q: use one of %s or set terminal.template", term, profileNames())
+    }
+    if !agents.HasBinary(p.Bin) { return Profile{}, fmt.Errorf("%s not found", p.Bin) }
+    return p, nil
+}
+
+func profileNames() string {
+    names := make([]string, 0, len(profiles))
+    for _, p := range profiles { names = append(names, p.Bin) }
+    return strings.Join(names, ", ")
+}
+
+// TerminalAvailable reports whether a new terminal window can be launched.
+func TerminalAvailable(cfg config.Config) bool {
+    if f := strings.Fields(cfg.Terminal.Template); len(f) > 0 {
+        if !strings.Contains(f[0], "{term}") { return agents.HasBinary(f[0]) }
+        term, err := templateTerm(cfg)
+        return err == nil && agents.HasBinary(strings.ReplaceAll(f[0], "{term}", term))
+    }
+    _, err := resolveProfile(cfg)
+    return err == nil
+}
+
+// windowCmd builds the command opening a terminal window in cwd that runs
+// shellCmd with bash -lc, or the user's shell when shellCmd is empty.
+func windowCmd(cwd, shellCmd string, cfg config.Config) (*exec.Cmd, error) {
+    if strings.TrimSpace(cfg.Terminal.Template) != "" { return templateCmd(cwd, shellCmd, cfg) }
+    p, err := resolveProfile(cfg)
+    if err != nil { return nil, err }
+    args := append([]string{}, p.Pre...)
+    for _, a := range p.Cwd { args = append(args, strings.ReplaceAll(a, "{cwd}", cwd)) }
+    if shellCmd != "" {
+        args = append(args, p.Exec...)
+        args = append(args, "bash", "-lc", shellCmd)
+    }
+    cmd := exec.Command(p.Bin, args...)
+    cmd.Dir = cwd
+    return cmd, nil
+}
+
+// templateTerm is what {term} stands for: terminal.prefer, or for "auto"
+// the first installed built-in terminal.
+func templateTerm(cfg config.Config) (string, error) {
+    if term := cfg.Terminal.Prefer; term != "" && term != "auto" { return term, nil }
+    p, err := resolveProfile(cfg)
+    if err != nil { return "", err }
+    return p.Bin, nil
+}
+
+// templateShell is what {shell} stands for: $SHELL, else bash.
+func templateShell() string {
+    if sh := os.Getenv("SHELL"); sh != "" { return sh }
+    return "bash"
+}
+
+// templateCmd expands terminal.template, e.g.
+// "{term} --cwd {cwd} -e {shell} -c {cmd}". The template is split on spaces
+// first, so each placeholder becomes (part of) a single argument whatever
+// it contains. Without a command, {cmd} starts the user's shell.
+func templateCmd(cwd, shellCmd string, cfg config.Config) (*exec.Cmd, error) {
+    if shellCmd == "" { shellCmd = `exec "${SHELL:-bash}"` }
+    term := ""
+    if strings.Contains(cfg.Terminal.Template, "{term}") {
+        t, err := templateTerm(cfg)
+        if err != nil { return nil, err }
+        term = t
+    }
+    r := strings.NewReplacer("{term}", term, "{cwd}", cwd, "{shell}", templateShell(), "{cmd}", shellCmd)
+    fields := strings.Fields(cfg.Terminal.Template)
+    for i, f := range fields { fields[i] = r.Replace(f) }
+    if !agents.HasBinary(fields[0]) { return nil, fmt.Errorf("%s not found", fields[0]) }
+    cmd := exec.Command(fields[0], fields[1:]...)
+    cmd.Dir = cwd
+    return cmd, nil
+}
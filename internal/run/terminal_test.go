@@ -0,0 +1,60 @@
+package run
+
+import (
+    "os"
+    "path/filepath"
+    "slices"
+    "testing"
+
+    "workflow/internal/config"
+)
+
+// fakeBins puts empty executables with the given names on a fresh PATH.
+func fakeBins(t *testing.T, names ...string) string {
+    dir := t.TempDir()
+    for _, n := range names {
+        if err := os.WriteFile(filepath.Join(dir, n), []byte("#!/bin/sh\n"), 0o755); err != nil { t.Fatal(err) }
+    }
+    t.Setenv("PATH", dir)
+    return dir
+}
+
+func TestTemplateCmd(t *testing.T) {
+    dir := fakeBins(t, "kitty", "myterm")
+    t.Setenv("SHELL", "/bin/zsh")
+    tpl := "{term} --cwd {cwd} -e {shell} -c {cmd}"
+    tests := []struct {
+        name, prefer, tpl string
+        want              []string
+    }{
+        {"auto picks the first installed", "auto", tpl, []string{"kitty", "--cwd", "/my dir", "-e", "/bin/zsh", "-c", "make test"}},
+        {"empty prefer is auto", "", tpl, []string{"kitty", "--cwd", "/my dir", "-e", "/bin/zsh", "-c", "make test"}},
+        {"prefer is used as is", "myterm", tpl, []string{"myterm", "--cwd", "/my dir", "-e", "/bin/zsh", "-c", "make test"}},
+        {"no {term}", "auto", "myterm -e {cmd}", []string{"myterm", "-e", "make test"}},
+    }
+    for _, tt := range tests {
+        cfg := config.Default()
+        cfg.Terminal.Prefer, cfg.Terminal.Template = tt.prefer, tt.tpl
+        cmd, err := templateCmd("/my dir", "make test", cfg)
+        if err != nil {
+            t.Errorf("%s: %v", tt.name, err)
+            continue
+        }
+        if got := append([]string{filepath.Base(cmd.Path)}, cmd.Args[1:]...); !slices.Equal(got, tt.want) { t.Errorf("%s: args %q, want %q", tt.name, got, tt.want) }
+        if filepath.Dir(cmd.Path) != dir { t.Errorf("%s: path %s not from PATH", tt.name, cmd.Path) }
+        if !TerminalAvailable(cfg) { t.Errorf("%s: terminal not available", tt.name) }
+    }
+}
+
+func TestTemplateCmdNoTerminal(t *testing.T) {
+    fakeBins(t)
+    cfg := config.Default()
+    cfg.Terminal.Template = "{term} -e {cmd}"
+    if _, err := templateCmd("/tmp", "true", cfg); err == nil { t.Error("auto without an installed terminal: want an error") }
+    if TerminalAvailable(cfg) { t.Error("auto without an installed terminal: reported available") }
+}
+
+func TestTemplateShellFallback(t *testing.T) {
+    t.Setenv("SHELL", "")
+    if got := templateShell(); got != "bash" { t.Errorf("templateShell() = %q, want bash", got) }
+}
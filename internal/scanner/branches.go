@@ -0,0 +1,75 @@
+package scanner
+
+import (
+    "os/exec"
+    "strconv"
+    "strings"
+)
+
+// BranchWork is a local branch with commits that may exist nowhere else.
+type BranchWork struct {
+    Name     string `json:"name"`
+    Upstream string `json:"upstream,omitempty"`
+    Ahead    int    `json:"ahead,omitempty"` // commits not on the upstream
+    Gone     bool   `json:"gone,omitempty"`  // the upstream branch was deleted
+    Local    int    `json:"local,omitempty"` // commits on no remote, for branches without a live upstream
+}
+
+// Describe says where the branch's work is missing, e.g. "ahead 2 of origin/x".
+func (b BranchWork) Describe() string {
+    plural := func(n int) string {
+        if n == 1 { return "1 commit" }
+        return strconv.Itoa(n) + " commits"
+    }
+    switch {
+    case b.Gone:
+        return "upstream " + b.Upstream + " gone, " + plural(b.Local) + " on no remote"
+    case b.Upstream != "":
+        return plural(b.Ahead) + " ahead of " + b.Upstream
+    }
+    return "no upstream, " + plural(b.Local) + " on no remote"
+}
+
+// unpushedBranches lists the local branches of the repo at path whose
+// commits are not all pushed: ahead of their upstream, upstream gone, or no
+// upstream and commits that no remote-tracking branch contains. Without
+// remotes there is nowhere to push and nothing is listed; otherwise only
+// branches without a live upstream cost a rev-list each.
+func unpushedBranches(path string, remotes bool) []BranchWork {
+    if !remotes { return nil }
+    out, err := exec.Command("git", "-C", path, "for-each-ref",
+        "--format=%(refname)%00%(upstream:short)%00%(upstream:track)", "refs/heads").Output()
+    if err != nil { return nil }
+    var work []BranchWork
+    for _, ln := range splitNonEmpty(string(out)) {
+        f := strings.Split(ln, "\x00")
+        if len(f) != 3 { continue }
+        b := BranchWork{Name: strings.TrimPrefix(f[0], "refs/heads/"), Upstream: f[1]}
+        track := strings.Trim(f[2], "[]")
+        switch {
+        case b.Upstream != "" && track == "gone":
+            b.Gone = true
+            b.Local = localCommits(path, b.Name)
+        case b.Upstream != "":
+            for _, part := range strings.Split(track, ", ") {
+                if n, ok := strings.CutPrefix(part, "ahead "); ok { b.Ahead, _ = strconv.Atoi(n) }
+            }
+            if b.Ahead == 0 { continue }
+            work = append(work, b)
+            continue
+        default:
+            b.Local = localCommits(path, b.Name)
+        }
+        if b.Local > 0 { work = append(work, b) }
+    }
+    return work
+}
+
+// localCommits counts the commits of branch that no remote-tracking branch
+// contains.
+func localCommits(path, branch string) int {
+    out, err := exec.Command("git", "-C", path, "rev-list", "--count", "refs/heads/"+branch, "--not", "--remotes").Output()
+    if err != nil { return 0 }
+    n, _ := strconv.Atoi(strings.TrimSpace(string(out)))
+    return n
+}
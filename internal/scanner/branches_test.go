@@ -0,0 +1,65 @@
+package scanner
+
+import (
+    "os/exec"
+    "path/filepath"
+    "reflect"
+    "testing"
+)
+
+// gitRepo runs git commands in dir, failing the test on error.
+func gitRepo(t *testing.T, dir string) func(args ...string) {
+    t.Helper()
+    if _, err := exec.LookPath("git"); err != nil { t.Skip("no git") }
+    for k, v := range map[string]string{
+        "GIT_AUTHOR_NAME": "t", "GIT_AUTHOR_EMAIL": "t@t", "GIT_COMMITTER_NAME": "t", "GIT_COMMITTER_EMAIL": "t@t",
+        "GIT_CONFIG_GLOBAL": "/dev/null", "GIT_CONFIG_NOSYSTEM": "1",
+    } {
+        t.Setenv(k, v)
+    }
+    return func(args ...string) {
+        t.Helper()
+        if out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput(); err != nil {
+            t.Fatalf("git %v: %v\n%s", args, err, out)
+        }
+    }
+}
+
+func TestUnpushedBranches(t *testing.T) {
+    tmp := t.TempDir()
+    origin, clone := filepath.Join(tmp, "origin.git"), filepath.Join(tmp, "clone")
+    gitRepo(t, tmp)("init", "-q", "--bare", "-b", "main", origin)
+    gitRepo(t, tmp)("clone", "-q", origin, clone)
+    git := gitRepo(t, clone)
+    git("commit", "-q", "--allow-empty", "-m", "init")
+    git("push", "-q", "-u", "origin", "main")
+
+    // pushed: tracks origin and is even with it
+    git("switch", "-q", "-c", "pushed")
+    git("push", "-q", "-u", "origin", "pushed")
+    // ahead: two commits on top of its upstream
+    git("switch", "-q", "-c", "feat/ahead")
+    git("push", "-q", "-u", "origin", "feat/ahead")
+    git("commit", "-q", "--allow-empty", "-m", "a1")
+    git("commit", "-q", "--allow-empty", "-m", "a2")
+    // gone: its upstream was deleted on the remote and pruned
+    git("switch", "-q", "-c", "gone", "main")
+    git("push", "-q", "-u", "origin", "gone")
+    git("commit", "-q", "--allow-empty", "-m", "g1")
+    git("push", "-q", "origin", "--delete", "gone")
+    git("fetch", "-q", "--prune")
+    // local: no upstream, one commit no remote has
+    git("switch", "-q", "-c", "local", "main")
+    git("commit", "-q", "--allow-empty", "-m", "l1")
+    // no upstream but nothing new: every commit is on origin/main
+    git("switch", "-q", "-c", "copy", "main")
+
+    want := []BranchWork{
+        {Name: "feat/ahead", Upstream: "origin/feat/ahead", Ahead: 2},
+        {Name: "gone", Upstream: "origin/gone", Gone: true, Local: 1},
+        {Name: "local", Local: 1},
+    }
+    if got := unpushedBranches(clone, true); !reflect.DeepEqual(got, want) { t.Errorf("unpushedBranches:\n got %+v\nwant %+v", got, want) }
+    if !hasRemote(GitDir(clone)) { t.Error("hasRemote: clone has origin") }
+    if got := unpushedBranches(clone, false); got != nil { t.Errorf("without remotes: %+v, want nil", got) }
+}
@@ -0,0 +1,108 @@
+package scanner
+
+import (
+    "hash/fnv"
+    "os"
+    "os/exec"
+    "path/filepath"
+    "strconv"
+    "strings"
+)
+
+// Refresh re-collects git status for an existing entry, keeping the identity
+// fields (name, workspace membership) that only a full scan can determine.
+func Refresh(e RepoEntry) RepoEntry {
+    st := collectRepo(e.Path)
+    st.Name = e.Name
+    st.Monorepo = e.Monorepo
+    st.WorkspacePkg = e.WorkspacePkg
+    st.PackageName = e.PackageName
+    st.ParentPath = e.ParentPath
+    return st
+}
+
+// GitDir returns the git directory for path. It follows "gitdir:" files used by
+// worktrees and submodules, and walks up for workspace packages that live
+// below the repo root. Returns "" if no git directory is found.
+func GitDir(path string) string {
+    dir := path
+    for {
+        p := filepath.Join(dir, ".git")
+        if fi, err := os.Stat(p); err == nil {
+            if fi.IsDir() { return p }
+            return readGitFile(dir, p)
+        }
+        parent := filepath.Dir(dir)
+        if parent == dir { return "" }
+        dir = parent
+    }
+}
+
+// readGitFile resolves a ".git" file of the form "gitdir: <path>".
+func readGitFile(dir, p string) string {
+    b, err := os.ReadFile(p)
+    if err != nil { return "" }
+    ln := strings.TrimSpace(string(b))
+    if !strings.HasPrefix(ln, "gitdir:") { return "" }
+    gd := strings.TrimSpace(strings.TrimPrefix(ln, "gitdir:"))
+    if !filepath.IsAbs(gd) { gd = filepath.Join(dir, gd) }
+    return filepath.Clean(gd)
+}
+
+// Fingerprint returns a cheap change marker for a repo built from the mtimes of
+// the git files that move when status changes: index, HEAD, the branch ref
+// HEAD points to (loose or packed), FETCH_HEAD, and refs/heads for branches
+// being created or deleted. It does not see edits to tracked files that
+// leave the index untouched.
+func Fingerprint(path string) string {
+    gd := GitDir(path)
+    if gd == "" { return "" }
+    common := commonDir(gd)
+    files := []string{
+        filepath.Join(gd, "index"),
+        filepath.Join(gd, "HEAD"),
+        filepath.Join(gd, "FETCH_HEAD"),
+        filepath.Join(common, "packed-refs"),
+        filepath.Join(common, "refs", "heads"),
+    }
+    if ref := headRef(gd); ref != "" { files = append(files, filepath.Join(common, filepath.FromSlash(ref))) }
+    parts := make([]string, 0, len(files))
+    for _, f := range files {
+        var mt int64
+        if fi, err := os.Stat(f); err == nil { mt = fi.ModTime().UnixNano() }
+        parts = append(parts, strconv.FormatInt(mt, 36))
+    }
+    return strings.Join(parts, ".")
+}
+
+// TrackedKey returns a digest of the status of the repo's tracked files, or
+// "" when git status fails. It changes when a tracked file is edited
+// anywhere in the working tree, which Fingerprint can't see; untracked
+// files aren't looked at, to keep it cheap.
+func TrackedKey(path string) string {
+    out, err := exec.Command("git", "--no-optional-locks", "-C", path, "status", "--porcelain=v2", "-uno", "-z").Output()
+    if err != nil { return "" }
+    h := fnv.New64a()
+    h.Write(out)
+    return strconv.FormatUint(h.Sum64(), 36)
+}
+
+// headRef returns the ref HEAD points to, e.g. "refs/heads/feat/x", or ""
+// when HEAD is detached or unreadable.
+func headRef(gd string) string {
+    b, err := os.ReadFile(filepath.Join(gd, "HEAD"))
+    if err != nil { return "" }
+    ref, ok := strings.CutPrefix(strings.TrimSpace(string(b)), "ref: ")
+    if !ok || !strings.HasPrefix(ref, "refs/") || strings.Contains(ref, "..") { return "" }
+    return ref
+}
+
+// commonDir returns the directory holding the refs shared by all worktrees
+// of the repo: gd itself, or the one named by a linked worktree's commondir.
+func commonDir(gd string) string {
+    b, err := os.ReadFile(filepath.Join(gd, "commondir"))
+    if err != nil { return gd }
+    cd := strings.TrimSpace(string(b))
+    if !filepath.IsAbs(cd) { cd = filepath.Join(gd, cd) }
+    return filepath.Clean(cd)
+}
@@ -1,7 +1,6 @@
 package scanner
 
 import (
-    "bufio"
     "encoding/json"
     "os"
     "os/exec"
@@ -18,23 +17,46 @@ import (
 )
 
 type RepoEntry struct {
-    Name    string
-    Path    string
-    Branch  string
-    Ahead   int
-    Behind  int
-    Dirty   bool
-    Conflicts int
-    LastAge string // e.g., 3d, 5h, 2mo
-    Detached bool
-    Monorepo bool       // parent has workspace members
-    WorkspacePkg bool   // this entry is a workspace/package under a monorepo
-    PackageName string  // optional package/crate name for workspace packages
-    ParentPath  string  // parent repo root for workspace packages
+    Name    string `json:"name"`
+    Path    string `json:"path"`
+    Branch  string `json:"branch"`
+    Ahead   int    `json:"ahead"`
+    Behind  int    `json:"behind"`
+    Dirty   bool   `json:"dirty"`
+    Conflicts int  `json:"conflicts"`
+    Staged    int  `json:"staged"`    // files with staged changes
+    Unstaged  int  `json:"unstaged"`  // files with unstaged changes
+    Untracked int  `json:"untracked"`
+    Stashes   int  `json:"stashes"`
+    Upstream  string `json:"upstream"` // e.g. origin/main
+    NoUpstream bool  `json:"no_upstream"` // on a branch without upstream in a repo with remotes
+    Operation  string `json:"operation"` // rebase, merge, cherry-pick, revert or bisect in progress
+    Unpushed   []BranchWork `json:"unpushed"` // local branches with commits not pushed
+    LastAge string `json:"last_age"` // e.g., 3d, 5h, 2mo
+    Detached bool  `json:"detached"`
+    Monorepo bool       `json:"monorepo"`      // parent has workspace members
+    WorkspacePkg bool   `json:"workspace_pkg"` // this entry is a workspace/package under a monorepo
+    PackageName string  `json:"package"` // optional package/crate name for workspace packages
+    ParentPath  string  `json:"parent"`  // parent repo root for workspace packages
+    Stale       bool    `json:"-"`       // loaded from the status cache, not yet rescanned
+}
+
+// Progress reports how many entries have been collected so far. Total grows
+// while scanning as workspace packages are discovered under parents.
+type Progress struct {
+    Done  int
+    Total int
 }
 
 // Scan finds git repos under roots (depth-limited) and collects status.
 func Scan(cfg config.Config) ([]RepoEntry, error) {
+    return ScanStream(cfg, nil)
+}
+
+// ScanStream is like Scan but calls emit for each entry as soon as its status
+// is collected, so callers can show results before the whole scan finishes.
+// Calls to emit are serialized; the returned slice matches Scan.
+func ScanStream(cfg config.Config, emit func(RepoEntry, Progress)) ([]RepoEntry, error) {
     roots := make([]string, 0, len(cfg.Roots))
     for _, r := range cfg.Roots {
         roots = append(roots, config.ExpandUser(r))
@@ -61,9 +83,36 @@ func Scan(cfg config.Config) ([]RepoEntry, error) {
         // update cache for this root
         cache.PutRepos(&cd, root, rs)
     }
-    _ = cache.Save(cd)
-    // Concurrency limited scan for parent repos
+    // Concurrency limited scan for parent repos. Each worker also discovers the
+    // repo's workspace packages so parents are emitted with Monorepo set and
+    // their children follow right after.
     out := make([]RepoEntry, len(repos))
+    children := make([][]RepoEntry, len(repos))
+    topLevel := make(map[string]struct{}, len(repos))
+    for _, p := range repos { topLevel[p] = struct{}{} }
+    var mu sync.Mutex
+    emitted := map[string]struct{}{}
+    // fingerprints are taken before collecting so a change racing the scan
+    // invalidates the snapshot instead of hiding behind it
+    fps := map[string]string{}
+    collect := func(p string) RepoEntry {
+        fp := Fingerprint(p)
+        mu.Lock()
+        fps[p] = fp
+        mu.Unlock()
+        return collectRepo(p)
+    }
+    prog := Progress{Total: len(repos)}
+    // publish records an entry once and forwards it to emit
+    publish := func(e RepoEntry) bool {
+        mu.Lock()
+        defer mu.Unlock()
+        if _, ok := emitted[e.Path]; ok { return false }
+        emitted[e.Path] = struct{}{}
+        prog.Done++
+        if emit != nil { emit(e, prog) }
+        return true
+    }
     var wg sync.WaitGroup
     sem := make(chan struct{}, max(8, 2*intConcurrency()))
     for i, p := range repos {
@@ -72,39 +121,46 @@ func Scan(cfg config.Config) ([]RepoEntry, error) {
         sem <- struct{}{}
         go func() {
             defer wg.Done()
-            entry := collectRepo(p)
+            defer func() { <-sem }()
+            entry := collect(p)
             entry.Name = filepath.Base(p)
-            out[i] = entry
-            <-sem
-        }()
-    }
-    wg.Wait()
-
-    // Discover monorepo workspace packages and append as separate rows
-    // while marking parent as Monorepo
-    parentIndex := map[string]int{}
-    for i, e := range out { parentIndex[e.Path] = i }
-    var children []RepoEntry
-    for _, e := range out {
-        ws := discoverWorkspaces(e.Path)
-        if len(ws) > 0 {
-            // mark parent
-            if idx, ok := parentIndex[e.Path]; ok {
-                out[idx].Monorepo = true
-            }
+            // Discover monorepo workspace packages and append as separate rows
+            // while marking parent as Monorepo
+            ws := discoverWorkspaces(p)
+            var pending []wsEntry
             for _, c := range ws {
-                child := collectRepo(c.Path)
+                // top-level repos are reported by their own worker
+                if _, ok := topLevel[c.Path]; ok { continue }
+                pending = append(pending, c)
+            }
+            if len(ws) > 0 { entry.Monorepo = true }
+            mu.Lock()
+            prog.Total += len(pending)
+            mu.Unlock()
+            out[i] = entry
+            publish(entry)
+            for _, c := range pending {
+                child := collect(c.Path)
                 // prefer package name if available
                 if c.PackageName != "" { child.Name = c.PackageName } else { child.Name = filepath.Base(c.Path) }
                 child.WorkspacePkg = true
-                child.ParentPath = e.Path
+                child.ParentPath = p
                 child.PackageName = c.PackageName
-                children = append(children, child)
+                if publish(child) {
+                    children[i] = append(children[i], child)
+                } else {
+                    mu.Lock()
+                    prog.Total--
+                    mu.Unlock()
+                }
             }
-        }
+        }()
     }
-    // Combine and dedupe by path
-    combined := append(out, children...)
+    wg.Wait()
+
+    // Combine: parents first, then children in parent order
+    combined := append([]RepoEntry(nil), out...)
+    for _, ch := range children { combined = append(combined, ch...) }
     uniq := make([]RepoEntry, 0, len(combined))
     seen := map[string]struct{}{}
     for _, e := range combined {
@@ -112,9 +168,44 @@ func Scan(cfg config.Config) ([]RepoEntry, error) {
         seen[e.Path] = struct{}{}
         uniq = append(uniq, e)
     }
+    // Snapshot statuses so the next start can show rows before scanning
+    for _, e := range uniq {
+        if b, err := json.Marshal(e); err == nil {
+            cache.PutStatus(&cd, e.Path, fps[e.Path], b)
+        }
+    }
+    cache.PruneStatus(&cd, seen)
+    _ = cache.Save(cd)
     return uniq, nil
 }
 
+// Cached returns status snapshots from the last scan for repos under the
+// configured roots, marked Stale. Snapshots whose fingerprint no longer
+// matches the repo on disk are skipped since they are known to be outdated.
+func Cached(cfg config.Config) []RepoEntry {
+    cd, err := cache.Load()
+    if err != nil { return nil }
+    roots := make([]string, 0, len(cfg.Roots))
+    for _, r := range cfg.Roots { roots = append(roots, filepath.Clean(config.ExpandUser(r))) }
+    var out []RepoEntry
+    for p, st := range cd.Status {
+        if !underAny(p, roots) { continue }
+        if st.Fingerprint == "" || st.Fingerprint != Fingerprint(p) { continue }
+        var e RepoEntry
+        if err := json.Unmarshal(st.Entry, &e); err != nil { continue }
+        e.Stale = true
+        out = append(out, e)
+    }
+    return out
+}
+
+func underAny(p string, roots []string) bool {
+    for _, r := range roots {
+        if p == r || strings.HasPrefix(p, r+string(os.PathSeparator)) { return true }
+    }
+    return false
+}
+
 func intConcurrency() int {
     n := 1
     if c := os.Getenv("GOMAXPROCS"); c != "" {
@@ -188,53 +279,20 @@ func isGitRepo(dir string) bool {
 
 func collectRepo(path string) RepoEntry {
     st := RepoEntry{Path: path}
-    // branch, ahead/behind, dirty/conflicts
-    branch, ahead, behind, dirty, conflicts := parseStatus(path)
-    st.Branch, st.Ahead, st.Behind, st.Dirty, st.Conflicts = branch, ahead, behind, dirty, conflicts
-    if branch == "(detached)" { st.Detached = true }
+    // branch, upstream, ahead/behind, file counts, stashes; an untracked
+    // directory counts once here
+    if gs, err := readStatus(path, false); err == nil { st.setStatus(gs) }
+    gd := GitDir(path)
+    st.Operation = operation(gd)
+    // repos without remotes have nowhere to push
+    remotes := hasRemote(gd)
+    if st.NoUpstream && !remotes { st.NoUpstream = false }
+    st.Unpushed = unpushedBranches(path, remotes)
     // last commit age
     st.LastAge = lastCommitAge(path)
     return st
 }
 
-func parseStatus(path string) (branch string, ahead, behind int, dirty bool, conflicts int) {
-    cmd := exec.Command("git", "-C", path, "status", "--porcelain=v2", "-b")
-    out, err := cmd.Output()
-    if err != nil {
-        return "", 0, 0, false, 0
-    }
-    s := bufio.NewScanner(strings.NewReader(string(out)))
-    for s.Scan() {
-        line := s.Text()
-        if strings.HasPrefix(line, "# branch.head ") {
-            branch = strings.TrimSpace(strings.TrimPrefix(line, "# branch.head "))
-            // Detect detached state reported as "(detached)"
-            if branch == "(detached)" {
-                // keep label for caller to mark as detached
-            }
-        } else if strings.HasPrefix(line, "# branch.ab ") {
-            // format: # branch.ab +A -B
-            parts := strings.Fields(line)
-            if len(parts) >= 4 {
-                if strings.HasPrefix(parts[2], "+") { ahead, _ = strconv.Atoi(parts[2][1:]) }
-                if strings.HasPrefix(parts[3], "-") { behind, _ = strconv.Atoi(parts[3][1:]) }
-            }
-        } else if len(line) > 0 {
-            switch line[0] {
-            case '1', '2':
-                dirty = true
-            case 'u':
-                conflicts++
-                dirty = true
-            case '?':
-                // untracked implies dirty
-                dirty = true
-            }
-        }
-    }
-    return
-}
-
 func lastCommitAge(path string) string {
     cmd := exec.Command("git", "-C", path, "log", "-1", "--format=%ct")
     out, err := cmd.Output()
@@ -244,7 +302,11 @@ func lastCommitAge(path string) string {
     tsStr := strings.TrimSpace(string(out))
     sec, err := strconv.ParseInt(tsStr, 10, 64)
     if err != nil { return "—" }
-    t := time.Unix(sec, 0)
+    return Age(time.Unix(sec, 0))
+}
+
+// Age formats the time since t like LastAge: now, 5h, 3d or 2mo.
+func Age(t time.Time) string {
     d := time.Since(t)
     if d < time.Hour {
         return "now"
@@ -259,6 +321,33 @@ func lastCommitAge(path string) string {
     return strconv.Itoa(months) + "mo"
 }
 
+// Bucket maps a LastAge string to an activity state: active, warm, stale or dormant.
+func Bucket(age string) string {
+    // age is formatted already; use rough mapping
+    if age == "now" { return "active" }
+    if strings.HasSuffix(age, "h") { return "active" }
+    if strings.HasSuffix(age, "d") {
+        // parse days
+        d, _ := strconv.Atoi(strings.TrimSuffix(age, "d"))
+        switch {
+        case d <= 3:
+            return "active"
+        case d <= 14:
+            return "warm"
+        case d <= 45:
+            return "stale"
+        default:
+            return "dormant"
+        }
+    }
+    if n, ok := strings.CutSuffix(age, "mo"); ok {
+        // 1mo is 30-59 days, either side of the 45-day line
+        if m, _ := strconv.Atoi(n); m >= 2 { return "dormant" }
+        return "stale"
+    }
+    return "stale"
+}
+
 // Workspace discovery
 type wsEntry struct {
     Path        string
@@ -0,0 +1,173 @@
+package scanner
+
+import (
+    "os"
+    "os/exec"
+    "path/filepath"
+    "strconv"
+    "strings"
+)
+
+// FileChange is a changed path of the working tree, from git status.
+type FileChange struct {
+    Path      string
+    Orig      string // source of a rename or copy
+    X         byte   // index status, '.' when unchanged
+    Y         byte   // work tree status, '.' when unchanged
+    Untracked bool
+    Conflict  bool
+}
+
+// Code is the two-letter status as git status --short shows it.
+func (f FileChange) Code() string {
+    if f.Untracked { return "??" }
+    code := []byte{f.X, f.Y}
+    for i, c := range code {
+        if c == '.' { code[i] = ' ' }
+    }
+    return string(code)
+}
+
+// Paths returns the file's path, preceded by the original path of a rename.
+func (f FileChange) Paths() []string {
+    if f.Orig != "" { return []string{f.Orig, f.Path} }
+    return []string{f.Path}
+}
+
+// Staged reports whether the index differs from HEAD for the file.
+func (f FileChange) Staged() bool { return !f.Untracked && !f.Conflict && f.X != '.' }
+
+// Unstaged reports whether the work tree differs from the index for the file.
+func (f FileChange) Unstaged() bool { return !f.Untracked && !f.Conflict && f.Y != '.' }
+
+// gitStatus is the parsed output of git status --porcelain=v2.
+type gitStatus struct {
+    branch        string
+    upstream      string
+    ahead, behind int
+    stashes       int
+    files         []FileChange
+}
+
+// readStatus runs git status in path. With allUntracked the files inside
+// untracked directories are listed one by one, which walks those
+// directories; otherwise such a directory is a single entry.
+func readStatus(path string, allUntracked bool) (gitStatus, error) {
+    untracked := "-unormal"
+    if allUntracked { untracked = "-uall" }
+    // --no-optional-locks keeps status from rewriting the index, which would
+    // otherwise retrigger the repo watcher after every refresh
+    cmd := exec.Command("git", "--no-optional-locks", "-C", path, "status", "--porcelain=v2", "-b", "--show-stash", untracked, "-z")
+    out, err := cmd.Output()
+    if err != nil { return gitStatus{}, err }
+    return parsePorcelain(string(out)), nil
+}
+
+// parsePorcelain parses NUL-separated porcelain v2 records.
+func parsePorcelain(out string) gitStatus {
+    var st gitStatus
+    recs := strings.Split(out, "\x00")
+    for i := 0; i < len(recs); i++ {
+        rec := recs[i]
+        if rec == "" { continue }
+        switch rec[0] {
+        case '#':
+            if v, ok := strings.CutPrefix(rec, "# branch.head "); ok {
+                // "(detached)" is kept for the caller to mark the repo detached
+                st.branch = strings.TrimSpace(v)
+            } else if v, ok := strings.CutPrefix(rec, "# branch.upstream "); ok {
+                st.upstream = strings.TrimSpace(v)
+            } else if v, ok := strings.CutPrefix(rec, "# stash "); ok {
+                st.stashes, _ = strconv.Atoi(strings.TrimSpace(v))
+            } else if strings.HasPrefix(rec, "# branch.ab ") {
+                // format: # branch.ab +A -B
+                parts := strings.Fields(rec)
+                if len(parts) >= 4 {
+                    if strings.HasPrefix(parts[2], "+") { st.ahead, _ = strconv.Atoi(parts[2][1:]) }
+                    if strings.HasPrefix(parts[3], "-") { st.behind, _ = strconv.Atoi(parts[3][1:]) }
+                }
+            }
+        case '1':
+            // 1 XY sub mH mI mW hH hI path
+            if f := strings.SplitN(rec, " ", 9); len(f) == 9 {
+                st.files = append(st.files, FileChange{Path: f[8], X: f[1][0], Y: f[1][1]})
+            }
+        case '2':
+            // 2 XY sub mH mI mW hH hI Xscore path, then the original path
+            if f := strings.SplitN(rec, " ", 10); len(f) == 10 {
+                fc := FileChange{Path: f[9], X: f[1][0], Y: f[1][1]}
+                if i+1 < len(recs) { i++; fc.Orig = recs[i] }
+                st.files = append(st.files, fc)
+            }
+        case 'u':
+            // u XY sub m1 m2 m3 mW h1 h2 h3 path
+            if f := strings.SplitN(rec, " ", 11); len(f) == 11 {
+                st.files = append(st.files, FileChange{Path: f[10], X: f[1][0], Y: f[1][1], Conflict: true})
+            }
+        case '?':
+            st.files = append(st.files, FileChange{Path: strings.TrimPrefix(rec, "? "), X: '?', Y: '?', Untracked: true})
+        }
+    }
+    return st
+}
+
+// setStatus fills the entry's status fields from git status.
+func (e *RepoEntry) setStatus(st gitStatus) {
+    e.Branch, e.Upstream = st.branch, st.upstream
+    e.Ahead, e.Behind, e.Stashes = st.ahead, st.behind, st.stashes
+    e.Dirty = len(st.files) > 0
+    for _, f := range st.files {
+        switch {
+        case f.Conflict:
+            e.Conflicts++
+        case f.Untracked:
+            e.Untracked++
+        }
+        if f.Staged() { e.Staged++ }
+        if f.Unstaged() { e.Unstaged++ }
+    }
+    // "(detached)" is what git reports without a branch
+    e.Detached = st.branch == "(detached)"
+    e.NoUpstream = !e.Detached && st.branch != "" && st.upstream == ""
+}
+
+// hasRemote reports whether the repo's config defines a remote.
+func hasRemote(gitDir string) bool {
+    if gitDir == "" { return false }
+    // worktrees share the config of the main git dir
+    if b, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+        cd := strings.TrimSpace(string(b))
+        if !filepath.IsAbs(cd) { cd = filepath.Join(gitDir, cd) }
+        gitDir = cd
+    }
+    b, err := os.ReadFile(filepath.Join(gitDir, "config"))
+    return err == nil && strings.Contains(string(b), "[remote \"")
+}
+
+// operations maps the marker files git leaves in the git dir to the
+// operation in progress, checked in order.
+var operations = []struct{ file, op string }{
+    {"rebase-merge", "rebase"},
+    {"rebase-apply", "rebase"},
+    {"MERGE_HEAD", "merge"},
+    {"CHERRY_PICK_HEAD", "cherry-pick"},
+    {"REVERT_HEAD", "revert"},
+    {"BISECT_LOG", "bisect"},
+}
+
+// operation returns the operation in progress in gitDir, or "".
+func operation(gitDir string) string {
+    if gitDir == "" { return "" }
+    for _, o := range operations {
+        if _, err := os.Stat(filepath.Join(gitDir, o.file)); err == nil { return o.op }
+    }
+    return ""
+}
+
+// ChangedFiles lists the repo's staged, unstaged, untracked and conflicted
+// files in git status order, including each file of untracked directories
+// so they can be diffed and staged one by one.
+func ChangedFiles(path string) ([]FileChange, error) {
+    st, err := readStatus(path, true)
+    return st.files, err
+}
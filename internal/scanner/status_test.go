@@ -0,0 +1,84 @@
+package scanner
+
+import (
+    "reflect"
+    "strings"
+    "testing"
+)
+
+// porcelain joins records the way git status --porcelain=v2 -z prints them.
+func porcelain(recs ...string) string { return strings.Join(recs, "\x00") + "\x00" }
+
+const (
+    oid  = "78981922613b2afb6025042ff6bd878ac1994e85"
+    oid2 = "f2ad6c76f0115a6ba5b00456a849810e7ec0af20"
+)
+
+func TestParsePorcelain(t *testing.T) {
+    tests := []struct {
+        name string
+        out  string
+        want gitStatus
+    }{
+        {
+            name: "branch with upstream, ahead/behind and stashes",
+            out: porcelain("# branch.oid "+oid, "# branch.head main", "# branch.upstream origin/main",
+                "# branch.ab +2 -1", "# stash 3"),
+            want: gitStatus{branch: "main", upstream: "origin/main", ahead: 2, behind: 1, stashes: 3},
+        },
+        {
+            name: "detached",
+            out:  porcelain("# branch.oid "+oid, "# branch.head (detached)"),
+            want: gitStatus{branch: "(detached)"},
+        },
+        {
+            name: "rename is two records",
+            out: porcelain("# branch.head main",
+                "2 R. N... 100644 100644 100644 "+oid+" "+oid+" R100 b new.txt", "a.txt",
+                "1 .M N... 100644 100644 100644 "+oid2+" "+oid2+" c file.txt"),
+            want: gitStatus{branch: "main", files: []FileChange{
+                {Path: "b new.txt", Orig: "a.txt", X: 'R', Y: '.'},
+                {Path: "c file.txt", X: '.', Y: 'M'},
+            }},
+        },
+        {
+            name: "unmerged",
+            out:  porcelain("u UU N... 100644 100644 100644 100644 "+oid+" "+oid2+" "+oid+" conflict file.txt"),
+            want: gitStatus{files: []FileChange{{Path: "conflict file.txt", X: 'U', Y: 'U', Conflict: true}}},
+        },
+        {
+            name: "untracked files and directories",
+            out:  porcelain("? new file.txt", "? sub dir/"),
+            want: gitStatus{files: []FileChange{
+                {Path: "new file.txt", X: '?', Y: '?', Untracked: true},
+                {Path: "sub dir/", X: '?', Y: '?', Untracked: true},
+            }},
+        },
+        {
+            name: "staged add with a leading space in the name",
+            out:  porcelain("1 A. N... 000000 100644 100644 "+strings.Repeat("0", 40)+" "+oid+"  lead.txt"),
+            want: gitStatus{files: []FileChange{{Path: " lead.txt", X: 'A', Y: '.'}}},
+        },
+        {
+            name: "empty",
+            out:  "",
+            want: gitStatus{},
+        },
+    }
+    for _, tt := range tests {
+        if got := parsePorcelain(tt.out); !reflect.DeepEqual(got, tt.want) { t.Errorf("%s:\n got %+v\nwant %+v", tt.name, got, tt.want) }
+    }
+}
+
+func TestSetStatusCounts(t *testing.T) {
+    st := parsePorcelain(porcelain("# branch.head main",
+        "1 MM N... 100644 100644 100644 "+oid+" "+oid2+" both.txt",
+        "2 R. N... 100644 100644 100644 "+oid+" "+oid+" R100 new.txt", "old.txt",
+        "u UU N... 100644 100644 100644 100644 "+oid+" "+oid2+" "+oid+" c.txt",
+        "? u.txt"))
+    var e RepoEntry
+    e.setStatus(st)
+    if e.Staged != 2 || e.Unstaged != 1 || e.Conflicts != 1 || e.Untracked != 1 || !e.Dirty || !e.NoUpstream {
+        t.Errorf("counts: %+v", e)
+    }
+}
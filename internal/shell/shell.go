@@ -0,0 +1,83 @@
+// Package shell builds POSIX shell command lines from untrusted values
+// (paths, file names) without letting them be interpreted as code.
+package shell
+
+import (
+    "regexp"
+    "slices"
+    "strings"
+)
+
+var safe = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// Quote returns s as a single shell word. Words made only of safe characters
+// are left bare; anything else is single-quoted, with embedded single quotes
+// written as '\''.
+func Quote(s string) string {
+    if s == "" { return "''" }
+    if safe.MatchString(s) { return s }
+    return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Join quotes each argument and joins them into a command line.
+func Join(args ...string) string {
+    q := make([]string, len(args))
+    for i, a := range args { q[i] = Quote(a) }
+    return strings.Join(q, " ")
+}
+
+var placeholder = regexp.MustCompile(`^\{([a-z_]+)\}`)
+
+// dquote escapes the characters that stay special inside double quotes.
+var dquote = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`")
+
+// Expand replaces {name} placeholders in tpl with the value of vars[name],
+// quoted for where it sits: shell-quoted outside quotes, backslash-escaped
+// inside "...", and spliced between closed and reopened quotes inside '...'.
+// Quotes a template puts right around a placeholder, as in cd "{cwd}", are
+// dropped so the value isn't quoted twice. Names listed in code hold shell
+// code (a command line) and are inserted verbatim. Unknown placeholders are
+// left as they are.
+func Expand(tpl string, vars map[string]string, code ...string) string {
+    var out []byte
+    quote := byte(0) // the quote of the span we are in
+    start := 0       // len(out) when that span opened
+    for i := 0; i < len(tpl); i++ {
+        c := tpl[i]
+        var sub []string
+        if c == '{' { sub = placeholder.FindStringSubmatch(tpl[i:]) }
+        if sub != nil {
+            if v, ok := vars[sub[1]]; ok {
+                end := i + len(sub[0])
+                wrapped := quote != 0 && len(out) == start && end < len(tpl) && tpl[end] == quote
+                switch {
+                case slices.Contains(code, sub[1]):
+                    out = append(out, v...)
+                case wrapped:
+                    out = append(out[:start-1], Quote(v)...)
+                    quote = 0
+                    end++
+                case quote == '"':
+                    out = append(out, dquote.Replace(v)...)
+                case quote == '\'':
+                    out = append(out, "'"+Quote(v)+"'"...)
+                default:
+                    out = append(out, Quote(v)...)
+                }
+                i = end - 1
+                continue
+            }
+        }
+        out = append(out, c)
+        switch {
+        case quote == 0 && (c == '\'' || c == '"'):
+            quote, start = c, len(out)
+        case c == quote:
+            quote = 0
+        case c == '\\' && quote != '\'' && i+1 < len(tpl):
+            i++
+            out = append(out, tpl[i])
+        }
+    }
+    return string(out)
+}
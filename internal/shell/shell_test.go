@@ -0,0 +1,85 @@
+package shell
+
+import (
+    "os/exec"
+    "testing"
+)
+
+// hostile are values that break naive quoting.
+var hostile = []string{
+    "plain",
+    "my dir",
+    "it's",
+    `say "hi"`,
+    "$HOME",
+    "`id`",
+    "$(id)",
+    "a\nb",
+    "-rf",
+    "",
+    `back\slash`,
+    `'"'`,
+    "{cwd}",
+}
+
+func TestQuote(t *testing.T) {
+    tests := []struct{ in, want string }{
+        {"plain", "plain"},
+        {"/a/b-c_d.txt", "/a/b-c_d.txt"},
+        {"my dir", "'my dir'"},
+        {"it's", `'it'\''s'`},
+        {`say "hi"`, `'say "hi"'`},
+        {"$HOME", "'$HOME'"},
+        {"`id`", "'`id`'"},
+        {"a\nb", "'a\nb'"},
+        {"-rf", "-rf"},
+        {"", "''"},
+    }
+    for _, tt := range tests {
+        if got := Quote(tt.in); got != tt.want { t.Errorf("Quote(%q) = %q, want %q", tt.in, got, tt.want) }
+    }
+}
+
+func TestExpand(t *testing.T) {
+    vars := map[string]string{"cwd": "my dir", "cmd": "echo hi"}
+    tests := []struct{ tpl, want string }{
+        {"cd {cwd}", "cd 'my dir'"},
+        {`cd "{cwd}"`, "cd 'my dir'"},
+        {"cd '{cwd}'", "cd 'my dir'"},
+        {`cd "$HOME/{cwd}"`, `cd "$HOME/my dir"`},
+        {"cd '/x/{cwd}/y'", "cd '/x/''my dir''/y'"},
+        {`echo \"{cwd}\"`, `echo \"'my dir'\"`},
+        {"sh -c {cmd}", "sh -c echo hi"},
+        {`sh -c "{cmd}"`, `sh -c "echo hi"`},
+        {"{unknown} {cwd}", "{unknown} 'my dir'"},
+        {"{", "{"},
+    }
+    for _, tt := range tests {
+        if got := Expand(tt.tpl, vars, "cmd"); got != tt.want { t.Errorf("Expand(%q) = %q, want %q", tt.tpl, got, tt.want) }
+    }
+}
+
+// TestExpandShell runs expanded templates through sh and checks that every
+// hostile value arrives as the exact text the template meant.
+func TestExpandShell(t *testing.T) {
+    if _, err := exec.LookPath("sh"); err != nil { t.Skip("no sh") }
+    tests := []struct{ tpl, pre, post string }{
+        {"printf '[%s]' {v}", "[", "]"},
+        {`printf '[%s]' "{v}"`, "[", "]"},
+        {"printf '[%s]' '{v}'", "[", "]"},
+        {`printf '[%s]' "/base/{v}/src"`, "[/base/", "/src]"},
+        {"printf '[%s]' '/base/{v}/src'", "[/base/", "/src]"},
+        {`printf '[%s]' pre"{v}"post`, "[pre", "post]"},
+    }
+    for _, tt := range tests {
+        for _, v := range hostile {
+            line := Expand(tt.tpl, map[string]string{"v": v})
+            out, err := exec.Command("sh", "-c", line).Output()
+            if err != nil {
+                t.Errorf("%q with %q: %s: %v", tt.tpl, v, line, err)
+                continue
+            }
+            if want := tt.pre + v + tt.post; string(out) != want { t.Errorf("%q with %q: %s printed %q, want %q", tt.tpl, v, line, out, want) }
+        }
+    }
+}
@@ -9,12 +9,40 @@ import (
 
     toml "github.com/pelletier/go-toml/v2"
     yaml "gopkg.in/yaml.v3"
+    "workflow/internal/shell"
 )
 
 type Task struct {
     Name string
     Cmd  string
-    Src  string // e.g., node, go, rust, make, scripts
+    Src  string // e.g., node, go, rust, make, scripts, repo (.workflow.yml)
+    Cwd  string // relative to the repo
+    Env  map[string]string
+}
+
+// Shell returns the command line running the task from the repo root, with
+// its cwd and env applied.
+func (t Task) Shell() string {
+    var b strings.Builder
+    if t.Cwd != "" && t.Cwd != "." { b.WriteString("cd " + shell.Quote(t.Cwd) + " && ") }
+    keys := make([]string, 0, len(t.Env))
+    for k := range t.Env { keys = append(keys, k) }
+    sort.Strings(keys)
+    for _, k := range keys { b.WriteString("export " + k + "=" + shell.Quote(t.Env[k]) + "; ") }
+    b.WriteString(t.Cmd)
+    return b.String()
+}
+
+// Merge puts custom tasks first and drops detected tasks they shadow by name.
+func Merge(custom, detected []Task) []Task {
+    if len(custom) == 0 { return detected }
+    names := make(map[string]bool, len(custom))
+    for _, t := range custom { names[t.Name] = true }
+    out := append([]Task{}, custom...)
+    for _, t := range detected {
+        if !names[t.Name] { out = append(out, t) }
+    }
+    return out
 }
 
 func Detect(path string) []Task {
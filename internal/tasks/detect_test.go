@@ -0,0 +1,49 @@
+package tasks
+
+import (
+    "slices"
+    "testing"
+)
+
+func TestShell(t *testing.T) {
+    tests := []struct {
+        task Task
+        want string
+    }{
+        {Task{Cmd: "make"}, "make"},
+        {Task{Cmd: "make", Cwd: "."}, "make"},
+        {Task{Cmd: "make", Cwd: "infra"}, "cd infra && make"},
+        {Task{Cmd: "make", Cwd: "my dir"}, "cd 'my dir' && make"},
+        {Task{Cmd: "make", Cwd: "it's"}, `cd 'it'\''s' && make`},
+        {Task{Cmd: "make", Env: map[string]string{"STAGE": "staging"}}, "export STAGE=staging; make"},
+        {Task{Cmd: "make", Env: map[string]string{"B": "2", "A": "1"}}, "export A=1; export B=2; make"},
+        {Task{Cmd: "make", Env: map[string]string{"MSG": "$(id) `id` a b"}}, "export MSG='$(id) `id` a b'; make"},
+        {Task{Cmd: "make", Env: map[string]string{"EMPTY": ""}}, "export EMPTY=''; make"},
+        {Task{Cmd: "make", Cwd: "infra", Env: map[string]string{"X": "y"}}, "cd infra && export X=y; make"},
+    }
+    for _, tt := range tests {
+        if got := tt.task.Shell(); got != tt.want { t.Errorf("%+v.Shell() = %q, want %q", tt.task, got, tt.want) }
+    }
+}
+
+func TestMerge(t *testing.T) {
+    names := func(ts []Task) []string {
+        out := make([]string, len(ts))
+        for i, t := range ts { out[i] = t.Name + "/" + t.Src }
+        return out
+    }
+    detected := []Task{{Name: "test", Src: "go"}, {Name: "build", Src: "make"}, {Name: "lint", Src: "node"}}
+    tests := []struct {
+        name   string
+        custom []Task
+        want   []string
+    }{
+        {"no custom tasks", nil, []string{"test/go", "build/make", "lint/node"}},
+        {"custom first", []Task{{Name: "seed", Src: "repo"}}, []string{"seed/repo", "test/go", "build/make", "lint/node"}},
+        {"shadows by name", []Task{{Name: "build", Src: "repo"}}, []string{"build/repo", "test/go", "lint/node"}},
+        {"shadows several", []Task{{Name: "lint", Src: "repo"}, {Name: "test", Src: "repo"}}, []string{"lint/repo", "test/repo", "build/make"}},
+    }
+    for _, tt := range tests {
+        if got := names(Merge(tt.custom, detected)); !slices.Equal(got, tt.want) { t.Errorf("%s: %v, want %v", tt.name, got, tt.want) }
+    }
+}
@@ -0,0 +1,188 @@
+package ui
+
+import (
+    "fmt"
+    "os/exec"
+    "strings"
+
+    "github.com/charmbracelet/bubbles/key"
+    "github.com/charmbracelet/bubbles/list"
+    tea "github.com/charmbracelet/bubbletea"
+    "workflow/internal/config"
+    "workflow/internal/gitutil"
+    "workflow/internal/run"
+    "workflow/internal/scanner"
+    "workflow/internal/shell"
+)
+
+// pendingConfirm is a y/N question in the status line; any other key
+// cancels it.
+type pendingConfirm struct {
+    prompt string
+    onYes  func(m *Model) tea.Cmd
+}
+
+// ask puts a confirmation in the status line.
+func (m *Model) ask(prompt string, onYes func(m *Model) tea.Cmd) {
+    m.confirm = &pendingConfirm{prompt: prompt, onYes: onYes}
+    m.status = prompt + " (y/N)"
+}
+
+type actionItem struct {
+    a     config.Action
+    repo  bool   // from the repo's .workflow.yml
+    taken string // what already has the action's key, if anything
+}
+
+func (i actionItem) Title() string {
+    t := i.a.Label()
+    if i.a.Key != "" { t = i.a.Key + "  " + t }
+    if i.repo { t += " (repo)" }
+    return t
+}
+func (i actionItem) Description() string {
+    if i.taken != "" { return "key " + i.a.Key + " is " + i.taken + " · " + i.a.Cmd }
+    return i.a.Cmd
+}
+func (i actionItem) FilterValue() string { return i.a.Label() + " " + i.a.Cmd }
+
+// actionsFor lists the actions for the repo at path: its .workflow.yml
+// actions first, then the global ones.
+func (m *Model) actionsFor(path string) []actionItem {
+    var out []actionItem
+    if path != "" {
+        rc, _ := config.LoadRepo(path)
+        _, taken := m.resolveRepoKeys(rc.Actions)
+        for i, a := range rc.Actions { out = append(out, actionItem{a: a, repo: true, taken: taken[i]}) }
+    }
+    for _, a := range m.cfg.Actions { out = append(out, actionItem{a: a}) }
+    return out
+}
+
+// repoKeys are the key-bound actions of the highlighted repo's .workflow.yml.
+type repoKeys struct {
+    path    string
+    actions map[string]config.Action
+}
+
+// resolveRepoKeys maps keys to the repo actions they run. Keys of the
+// keymap, navigation keys and keys of an earlier repo action win; taken
+// says, per action, what has its key instead.
+func (m Model) resolveRepoKeys(actions []config.Action) (map[string]config.Action, map[int]string) {
+    keyed := map[string]config.Action{}
+    taken := map[int]string{}
+    for i, a := range actions {
+        if a.Key == "" || a.Cmd == "" { continue }
+        if owner := config.KeyOwner(m.cfg.Keys, a.Key); owner != "" {
+            taken[i] = owner
+        } else if prev, ok := keyed[a.Key]; ok {
+            taken[i] = "action " + prev.Label()
+        } else {
+            keyed[a.Key] = a
+        }
+    }
+    return keyed, taken
+}
+
+// syncRepoKeys resolves the repo action keys when the cursor moves to
+// another repo, so key presses don't reload the repo config.
+func (m *Model) syncRepoKeys() {
+    path := m.currentPath()
+    if path == m.repoKeys.path { return }
+    m.repoKeys = repoKeys{path: path}
+    if path == "" { return }
+    rc, _ := config.LoadRepo(path)
+    m.repoKeys.actions, _ = m.resolveRepoKeys(rc.Actions)
+}
+
+// openActionPicker lists the actions available for the current repo.
+func (m *Model) openActionPicker() {
+    acts := m.actionsFor(m.currentPath())
+    if len(acts) == 0 {
+        m.status = "no actions configured"
+        return
+    }
+    items := make([]list.Item, 0, len(acts))
+    for _, a := range acts { items = append(items, a) }
+    m.actionItems = m.setupThemedList(items, "Run action")
+    m.showActions = true
+}
+
+// tableKey reports whether the table handles msg (navigation), so a user
+// action bound to the same key never fires.
+func (m Model) tableKey(msg tea.KeyMsg) bool {
+    km := m.table.KeyMap
+    return key.Matches(msg, km.LineUp, km.LineDown, km.PageUp, km.PageDown, km.HalfPageUp, km.HalfPageDown, km.GotoTop, km.GotoBottom)
+}
+
+// keyAction runs the action bound to k, if any: the highlighted repo's
+// first, then the global ones.
+func (m *Model) keyAction(k string) (tea.Cmd, bool) {
+    if a, ok := m.repoKeys.actions[k]; ok { return m.triggerAction(a), true }
+    if a, ok := config.FindAction(m.cfg.Actions, k); ok { return m.triggerAction(a), true }
+    return nil, false
+}
+
+// triggerAction runs a for the selection or the current repo, asking first
+// when the action wants confirmation.
+func (m *Model) triggerAction(a config.Action) tea.Cmd {
+    ts := m.targets()
+    if len(ts) == 0 { m.status = "no selection"; return nil }
+    if !a.Confirm { return m.runAction(a, ts) }
+    where := ts[0].Name
+    if len(ts) > 1 { where = fmt.Sprintf("%d repos", len(ts)) }
+    m.ask(fmt.Sprintf("run %s in %s?", a.Label(), where), func(m *Model) tea.Cmd { return m.runAction(a, ts) })
+    return nil
+}
+
+// actionVars are the placeholder values for running cmd in r.
+func (m *Model) actionVars(r scanner.RepoEntry, cmd string) map[string]string {
+    name := r.Name
+    if n := m.cfg.DisplayName(r.Path); n != "" { name = n }
+    v := map[string]string{"path": r.Path, "name": name, "branch": r.Branch, "package": r.PackageName}
+    if strings.Contains(cmd, "{remote_url}") { v["remote_url"], _ = gitutil.RemoteURL(r.Path) }
+    return v
+}
+
+// runAction runs a in each of ts. Background actions, and any action over
+// several repos that would otherwise take over this terminal, run as jobs.
+func (m *Model) runAction(a config.Action, ts []scanner.RepoEntry) tea.Cmd {
+    label := a.Label()
+    mode := a.Mode
+    if mode == "" { mode = m.cfg.Terminal.ModeFor("action") }
+    if mode != "background" { mode = m.launchMode(mode) }
+    if len(ts) == 1 {
+        e := ts[0]
+        cmd := shell.Expand(a.Cmd, m.actionVars(e, a.Cmd))
+        if mode != "background" {
+            return m.openAppMode(e.Path, mode, label, cmd,
+                func() *exec.Cmd { return run.InPlaceCmd(e.Path, cmd) },
+                func() (*exec.Cmd, error) { return run.ShellCmd(e.Path, cmd, m.cfg) })
+        }
+        if err := m.launch(e.Path, label, func() (*exec.Cmd, error) { return run.InPlaceCmd(e.Path, cmd), nil }); err != nil {
+            m.status = label + ": " + err.Error()
+        } else {
+            m.status = "started " + label + " (J for output)"
+        }
+        return nil
+    }
+    return runEach(label, ts, func(e scanner.RepoEntry) (string, error) {
+        cmd := shell.Expand(a.Cmd, m.actionVars(e, a.Cmd))
+        switch mode {
+        case "mux":
+            return m.startMux(e.Path, label, cmd)
+        case "new_window":
+            return "opened", m.launch(e.Path, label, func() (*exec.Cmd, error) { return run.ShellCmd(e.Path, cmd, m.cfg) })
+        }
+        return "started (J for output)", m.launch(e.Path, label, func() (*exec.Cmd, error) { return run.InPlaceCmd(e.Path, cmd), nil })
+    })
+}
+
+// actionsHelp lists the global actions bound to keys for the help footer.
+func (m Model) actionsHelp() string {
+    var parts []string
+    for _, a := range m.cfg.Actions {
+        if a.Key != "" && a.Cmd != "" { parts = append(parts, a.Key+" "+a.Label()) }
+    }
+    return strings.Join(parts, "  ")
+}
@@ -0,0 +1,261 @@
+package ui
+
+import (
+    "fmt"
+    "path/filepath"
+    "strings"
+
+    "github.com/charmbracelet/bubbles/list"
+    "github.com/charmbracelet/bubbles/textinput"
+    tea "github.com/charmbracelet/bubbletea"
+    "workflow/internal/gitutil"
+    "workflow/internal/scanner"
+)
+
+type branchesMsg struct {
+    repo     string
+    base     string
+    branches []gitutil.Branch
+    err      error
+}
+
+type branchOpMsg struct {
+    repo string
+    note string
+    err  error
+}
+
+type branchItem struct {
+    b    gitutil.Branch
+    base string
+}
+
+func (i branchItem) Title() string {
+    if i.b.Current { return "● " + i.b.Name }
+    return "  " + i.b.Name
+}
+
+func (i branchItem) Description() string {
+    b := i.b
+    parts := []string{"  " + scanner.Age(b.Time)}
+    if i.base != "" && b.Name != i.base {
+        parts = append(parts, fmt.Sprintf("⇡%d ⇣%d vs %s", b.Ahead, b.Behind, i.base))
+        if b.Merged { parts = append(parts, "merged") }
+    }
+    if b.Upstream != "" { parts = append(parts, "upstream "+b.Upstream) }
+    return strings.Join(parts, " · ")
+}
+
+func (i branchItem) FilterValue() string { return i.b.Name }
+
+func loadBranchesCmd(repo string) tea.Cmd {
+    return func() tea.Msg {
+        base := gitutil.DefaultBranch(repo)
+        bs, err := gitutil.Branches(repo, base)
+        return branchesMsg{repo: repo, base: base, branches: bs, err: err}
+    }
+}
+
+// openBranches shows the branches of the current repo.
+func (m *Model) openBranches() tea.Cmd {
+    path := m.currentPath()
+    if path == "" {
+        m.status = "no selection"
+        return nil
+    }
+    m.branchRepo = path
+    m.branchItems = m.setupThemedList(nil, "Branches — "+filepath.Base(path))
+    m.branchItems.SetFilteringEnabled(true)
+    m.branchItems.SetSize(min(80, m.width-4), min(14, m.height-8))
+    m.branchPrompt = ""
+    m.showBranches = true
+    m.status = "loading branches…"
+    m.updateTableHeight()
+    return loadBranchesCmd(path)
+}
+
+func (m *Model) setBranches(msg branchesMsg) {
+    if !m.showBranches || msg.repo != m.branchRepo { return }
+    if msg.err != nil {
+        m.status = "branches: " + msg.err.Error()
+        return
+    }
+    if m.status == "loading branches…" { m.status = "" }
+    m.branchBase = msg.base
+    idx := m.branchItems.Index()
+    items := make([]list.Item, len(msg.branches))
+    for i, b := range msg.branches { items[i] = branchItem{b: b, base: msg.base} }
+    m.branchItems.SetItems(items)
+    if len(items) > 0 { m.branchItems.Select(min(idx, len(items)-1)) }
+}
+
+// branchOp runs a branch command in the background.
+func (m *Model) branchOp(note string, fn func(repo string) error) tea.Cmd {
+    repo := m.branchRepo
+    return func() tea.Msg { return branchOpMsg{repo: repo, note: note, err: fn(repo)} }
+}
+
+// branchOpDone reports an operation, reloads the list and rescans the row.
+func (m *Model) branchOpDone(msg branchOpMsg) tea.Cmd {
+    if msg.err != nil {
+        m.status = msg.note + ": " + msg.err.Error()
+    } else {
+        m.status = msg.note
+    }
+    cmds := []tea.Cmd{loadBranchesCmd(msg.repo)}
+    if i := m.repoIndex(msg.repo); i >= 0 { cmds = append(cmds, refreshRepoCmd(m.repos[i])) }
+    return tea.Batch(cmds...)
+}
+
+// keepBranch says why b can't be deleted from the overlay, or "" when it
+// can: only merged local branches other than the checked-out one and the
+// default's local twin go.
+func (m Model) keepBranch(b gitutil.Branch) string {
+    _, baseLocal, ok := strings.Cut(m.branchBase, "/")
+    if !ok { baseLocal = m.branchBase }
+    switch {
+    case b.Remote:
+        return "remote branches aren't deleted from here"
+    case b.Current:
+        return "can't delete the checked-out branch"
+    case b.Name == baseLocal:
+        return b.Name + " is the default branch"
+    case !b.Merged:
+        return b.Name + " isn't merged into " + m.branchBase
+    }
+    return ""
+}
+
+// mergedBranches are the local branches delete-merged removes.
+func (m Model) mergedBranches() []string {
+    var names []string
+    for _, it := range m.branchItems.Items() {
+        if b := it.(branchItem).b; m.keepBranch(b) == "" { names = append(names, b.Name) }
+    }
+    return names
+}
+
+// promptBranch asks for a branch name in the overlay; what is "create" or
+// "rename".
+func (m *Model) promptBranch(what, value string) {
+    ti := textinput.New()
+    ti.Prompt = what + ": "
+    ti.CharLimit = 200
+    ti.SetValue(value)
+    ti.Focus()
+    m.branchInput = ti
+    m.branchPrompt = what
+    m.updateTableHeight()
+}
+
+// branchesKey handles keys while the branch overlay is open.
+func (m Model) branchesKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+    if m.branchPrompt != "" { return m.branchPromptKey(msg) }
+    if m.branchItems.FilterState() == list.Filtering {
+        var cmd tea.Cmd
+        m.branchItems, cmd = m.branchItems.Update(msg)
+        return m, cmd
+    }
+    it, ok := m.branchItems.SelectedItem().(branchItem)
+    b := it.b
+    switch msg.String() {
+    case "esc", "q":
+        if m.branchItems.FilterState() == list.FilterApplied {
+            m.branchItems.ResetFilter()
+            return m, nil
+        }
+        m.showBranches = false
+        if m.status == "loading branches…" { m.status = "" }
+        m.updateTableHeight()
+        return m, nil
+    case "enter":
+        if !ok { return m, nil }
+        if b.Current {
+            m.status = "already on " + b.Name
+            return m, nil
+        }
+        m.status = "switching to " + b.Name + "…"
+        return m, m.branchOp("switched to "+b.Name, func(repo string) error { return gitutil.Checkout(repo, b) })
+    case "n":
+        m.promptBranch("create", "")
+        return m, nil
+    case "R":
+        if !ok || b.Remote {
+            m.status = "only local branches can be renamed"
+            return m, nil
+        }
+        m.promptBranch("rename", b.Name)
+        return m, nil
+    case "x":
+        if !ok { return m, nil }
+        if why := m.keepBranch(b); why != "" {
+            m.status = why
+            return m, nil
+        }
+        base := m.branchBase
+        m.ask("delete branch "+b.Name+"?", func(m *Model) tea.Cmd {
+            return m.branchOp("deleted "+b.Name, func(repo string) error { return gitutil.DeleteBranch(repo, b.Name, base) })
+        })
+        return m, nil
+    case "D":
+        names := m.mergedBranches()
+        if len(names) == 0 {
+            m.status = "no merged branches to delete"
+            return m, nil
+        }
+        base := m.branchBase
+        list := strings.Join(names, ", ")
+        if len(names) > 3 { list = strings.Join(names[:3], ", ") + ", …" }
+        m.ask(fmt.Sprintf("delete %d branches merged into %s (%s)?", len(names), m.branchBase, list), func(m *Model) tea.Cmd {
+            return m.branchOp(fmt.Sprintf("deleted %d merged branches", len(names)), func(repo string) error {
+                for _, n := range names {
+                    if err := gitutil.DeleteBranch(repo, n, base); err != nil { return err }
+                }
+                return nil
+            })
+        })
+        return m, nil
+    }
+    var cmd tea.Cmd
+    m.branchItems, cmd = m.branchItems.Update(msg)
+    return m, cmd
+}
+
+// branchPromptKey edits the name for create or rename.
+func (m Model) branchPromptKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+    switch msg.String() {
+    case "esc":
+        m.branchPrompt = ""
+        m.updateTableHeight()
+        return m, nil
+    case "enter":
+        what, name := m.branchPrompt, strings.TrimSpace(m.branchInput.Value())
+        m.branchPrompt = ""
+        m.updateTableHeight()
+        if name == "" { return m, nil }
+        if what == "create" {
+            return m, m.branchOp("created "+name, func(repo string) error { return gitutil.CreateBranch(repo, name) })
+        }
+        it, ok := m.branchItems.SelectedItem().(branchItem)
+        if !ok || it.b.Name == name { return m, nil }
+        from := it.b.Name
+        return m, m.branchOp("renamed "+from+" to "+name, func(repo string) error { return gitutil.RenameBranch(repo, from, name) })
+    }
+    var cmd tea.Cmd
+    m.branchInput, cmd = m.branchInput.Update(msg)
+    return m, cmd
+}
+
+// branchesView renders the list with key hints, the name prompt and the
+// status line.
+func (m Model) branchesView() string {
+    var b strings.Builder
+    fmt.Fprintln(&b, m.branchItems.View())
+    if m.branchPrompt != "" {
+        fmt.Fprintln(&b, m.branchInput.View())
+    } else {
+        fmt.Fprintln(&b, statusStyle.Faint(true).Render("enter checkout  n new  R rename  x delete  D delete merged  / filter  esc close"))
+    }
+    fmt.Fprint(&b, statusStyle.Render(m.status))
+    return b.String()
+}
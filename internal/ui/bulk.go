@@ -0,0 +1,210 @@
+package ui
+
+import (
+    "context"
+    "fmt"
+    "os/exec"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+
+    tea "github.com/charmbracelet/bubbletea"
+    "github.com/charmbracelet/bubbles/list"
+    "github.com/charmbracelet/lipgloss"
+    "workflow/internal/config"
+    "workflow/internal/gitsync"
+    "workflow/internal/gitutil"
+    "workflow/internal/scanner"
+    "workflow/internal/tasks"
+)
+
+// bulkConcurrency bounds parallel git operations across a selection.
+const bulkConcurrency = 6
+
+// bulkResult is the outcome of an action on one repo.
+type bulkResult struct {
+    Name string
+    Path string
+    OK   bool
+    Skip bool // nothing done on purpose (e.g. sync skipped a dirty repo)
+    Msg  string
+}
+
+type bulkDoneMsg struct {
+    Title   string
+    Results []bulkResult
+    Entries []scanner.RepoEntry // repos to rescan afterwards
+}
+
+// toggleSelected flips selection of the row under the cursor.
+func (m *Model) toggleSelected() {
+    p := m.currentPath()
+    if p == "" { return }
+    if m.selected[p] { delete(m.selected, p) } else { m.selected[p] = true }
+}
+
+// selectAllVisible selects every row currently shown.
+func (m *Model) selectAllVisible() {
+    for _, ri := range m.visible { m.selected[m.repos[ri].Path] = true }
+}
+
+// invertVisible inverts the selection of the rows currently shown.
+func (m *Model) invertVisible() {
+    for _, ri := range m.visible {
+        p := m.repos[ri].Path
+        if m.selected[p] { delete(m.selected, p) } else { m.selected[p] = true }
+    }
+}
+
+// targets returns the selected repos in table order, or the current row when
+// nothing is selected.
+func (m *Model) targets() []scanner.RepoEntry {
+    var out []scanner.RepoEntry
+    if len(m.selected) > 0 {
+        for _, r := range m.repos {
+            if m.selected[r.Path] { out = append(out, r) }
+        }
+        return out
+    }
+    if len(m.visible) == 0 { return nil }
+    idx := m.table.Cursor()
+    if idx < 0 || idx >= len(m.visible) { return nil }
+    return []scanner.RepoEntry{m.repos[m.visible[idx]]}
+}
+
+// bulkCmd runs fn for each repo with bounded concurrency off the Update path.
+func bulkCmd(title string, entries []scanner.RepoEntry, fn func(scanner.RepoEntry) (string, error)) tea.Cmd {
+    return func() tea.Msg {
+        res := make([]bulkResult, len(entries))
+        var wg sync.WaitGroup
+        sem := make(chan struct{}, bulkConcurrency)
+        for i, e := range entries {
+            i, e := i, e
+            wg.Add(1)
+            sem <- struct{}{}
+            go func() {
+                defer wg.Done()
+                defer func() { <-sem }()
+                msg, err := fn(e)
+                res[i] = bulkResult{Name: e.Name, Path: e.Path, OK: err == nil, Msg: msg}
+                if err != nil { res[i].Msg = err.Error() }
+            }()
+        }
+        wg.Wait()
+        return bulkDoneMsg{Title: title, Results: res, Entries: entries}
+    }
+}
+
+// runEach applies a quick, synchronous action (e.g. spawning a window) to
+// every target and reports the results the same way as bulkCmd.
+func runEach(title string, entries []scanner.RepoEntry, fn func(scanner.RepoEntry) (string, error)) tea.Cmd {
+    res := make([]bulkResult, 0, len(entries))
+    for _, e := range entries {
+        msg, err := fn(e)
+        r := bulkResult{Name: e.Name, Path: e.Path, OK: err == nil, Msg: msg}
+        if err != nil { r.Msg = err.Error() }
+        res = append(res, r)
+    }
+    return func() tea.Msg { return bulkDoneMsg{Title: title, Results: res} }
+}
+
+// showBulkResults fills the results panel.
+func (m *Model) showBulkResults(title string, res []bulkResult) {
+    ok, skipped := 0, 0
+    for _, r := range res {
+        if r.Skip { skipped++ } else if r.OK { ok++ }
+    }
+    good := colorBadge("✓", m.th, "green")
+    bad := colorBadge("✗", m.th, "red")
+    skip := colorBadge("–", m.th, "yellow")
+    var sb strings.Builder
+    for _, r := range res {
+        mark := good
+        if r.Skip { mark = skip } else if !r.OK { mark = bad }
+        line := mark + " " + r.Name
+        if r.Msg != "" { line += " — " + r.Msg }
+        fmt.Fprintln(&sb, line)
+    }
+    m.resultsTitle = fmt.Sprintf("%s: %d ok, %d failed", title, ok, len(res)-ok-skipped)
+    if skipped > 0 { m.resultsTitle += fmt.Sprintf(", %d skipped", skipped) }
+    m.results.SetContent(sb.String())
+    m.results.GotoTop()
+    m.showResults = true
+    m.status = m.resultsTitle
+    m.updateTableHeight()
+}
+
+func (m Model) resultsView() string {
+    head := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(pickAccent(m.th.Colors, m.th.Dark))).
+        Render(m.resultsTitle + " (Esc to close)")
+    return head + "\n" + m.results.View()
+}
+
+// syncCmd fast-forwards entries via gitsync and reports outcomes in the results panel.
+func syncCmd(cfg config.Config, jobs *jobManager, entries []scanner.RepoEntry) tea.Cmd {
+    return func() tea.Msg {
+        opts := gitsync.Options{
+            Jobs:    cfg.Git.Jobs,
+            Fetch:   true,
+            Timeout: time.Duration(cfg.Git.TimeoutSeconds) * time.Second,
+            Exec: func(e scanner.RepoEntry, label string, cmd *exec.Cmd) ([]byte, error) {
+                args := cmd.Args[3:] // after "git -C <path>"
+                return jobs.run(e.Path, e.Name, label, gitutil.Batch(cmd), rerunGit(e.Path, args...), time.Duration(cfg.Git.TimeoutSeconds)*time.Second)
+            },
+        }
+        rs := gitsync.Run(context.Background(), entries, opts, nil)
+        res := make([]bulkResult, 0, len(rs))
+        for _, r := range rs {
+            msg := string(r.Outcome)
+            if r.Reason != "" { msg += ": " + r.Reason }
+            res = append(res, bulkResult{
+                Name: r.Entry.Name, Path: r.Entry.Path,
+                OK:   r.Outcome != gitsync.Failed,
+                Skip: r.Outcome == gitsync.Skipped,
+                Msg:  msg,
+            })
+        }
+        return bulkDoneMsg{Title: "sync", Results: res, Entries: entries}
+    }
+}
+
+// bulkTaskItems lists tasks available in any of the targets, by name.
+func bulkTaskItems(entries []scanner.RepoEntry) ([]tasks.Task, []list.Item) {
+    count := map[string]int{}
+    first := map[string]tasks.Task{}
+    for _, e := range entries {
+        seen := map[string]bool{}
+        ts, _ := repoTasks(e.Path)
+        for _, t := range ts {
+            if seen[t.Name] { continue }
+            seen[t.Name] = true
+            if _, ok := first[t.Name]; !ok { first[t.Name] = t }
+            count[t.Name]++
+        }
+    }
+    names := make([]string, 0, len(first))
+    for n := range first { names = append(names, n) }
+    sort.Slice(names, func(i, j int) bool {
+        if count[names[i]] != count[names[j]] { return count[names[i]] > count[names[j]] }
+        return names[i] < names[j]
+    })
+    ts := make([]tasks.Task, 0, len(names))
+    items := make([]list.Item, 0, len(names))
+    for _, n := range names {
+        t := first[n]
+        ts = append(ts, t)
+        t.Cmd = fmt.Sprintf("in %d of %d repos", count[n], len(entries))
+        items = append(items, taskItem{Task: t})
+    }
+    return ts, items
+}
+
+// findTask returns the task named name in path.
+func findTask(path, name string) (tasks.Task, bool) {
+    ts, _ := repoTasks(path)
+    for _, t := range ts {
+        if t.Name == name { return t, true }
+    }
+    return tasks.Task{}, false
+}
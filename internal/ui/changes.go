@@ -0,0 +1,293 @@
+package ui
+
+import (
+    "bytes"
+    "fmt"
+    "path/filepath"
+    "strings"
+
+    "github.com/alecthomas/chroma/v2"
+    "github.com/alecthomas/chroma/v2/formatters"
+    "github.com/alecthomas/chroma/v2/lexers"
+    "github.com/charmbracelet/bubbles/list"
+    tea "github.com/charmbracelet/bubbletea"
+    "workflow/internal/gitutil"
+    "workflow/internal/scanner"
+    "workflow/internal/theme"
+)
+
+type changesMsg struct {
+    repo  string
+    files []scanner.FileChange
+    err   error
+}
+
+type diffMsg struct {
+    repo string
+    file string
+    text string
+    err  error
+}
+
+type fileOpMsg struct {
+    repo string
+    note string
+    err  error
+}
+
+type changeItem struct{ f scanner.FileChange }
+
+func (c changeItem) Title() string {
+    name := c.f.Path
+    if c.f.Orig != "" { name = c.f.Orig + " → " + c.f.Path }
+    return c.f.Code() + "  " + name
+}
+func (c changeItem) Description() string { return describeChange(c.f) }
+func (c changeItem) FilterValue() string { return c.f.Path }
+
+// describeChange spells out a file's status, e.g. "staged: modified".
+func describeChange(f scanner.FileChange) string {
+    switch {
+    case f.Conflict:
+        return "conflict"
+    case f.Untracked:
+        return "untracked"
+    }
+    var parts []string
+    if f.Staged() { parts = append(parts, "staged: "+changeWord(f.X)) }
+    if f.Unstaged() { parts = append(parts, "unstaged: "+changeWord(f.Y)) }
+    return strings.Join(parts, ", ")
+}
+
+func changeWord(c byte) string {
+    switch c {
+    case 'M':
+        return "modified"
+    case 'A':
+        return "added"
+    case 'D':
+        return "deleted"
+    case 'R':
+        return "renamed"
+    case 'C':
+        return "copied"
+    case 'T':
+        return "type changed"
+    }
+    return string(c)
+}
+
+func loadChangesCmd(repo string) tea.Cmd {
+    return func() tea.Msg {
+        files, err := scanner.ChangedFiles(repo)
+        return changesMsg{repo: repo, files: files, err: err}
+    }
+}
+
+// loadDiffCmd diffs f in the background: staged and unstaged changes one
+// after the other, highlighted with style.
+func loadDiffCmd(repo string, f scanner.FileChange, style *chroma.Style) tea.Cmd {
+    return func() tea.Msg {
+        var parts []string
+        add := func(cached, untracked bool) error {
+            d, err := gitutil.Diff(repo, cached, untracked, f.Paths()...)
+            if err != nil { return err }
+            if d != "" { parts = append(parts, d) }
+            return nil
+        }
+        var err error
+        switch {
+        case f.Untracked:
+            err = add(false, true)
+        case f.Conflict:
+            err = add(false, false)
+        default:
+            if f.Staged() { err = add(true, false) }
+            if err == nil && f.Unstaged() { err = add(false, false) }
+        }
+        if err != nil { return diffMsg{repo: repo, file: f.Path, err: err} }
+        if len(parts) == 0 { return diffMsg{repo: repo, file: f.Path, text: "(no textual changes)"} }
+        return diffMsg{repo: repo, file: f.Path, text: highlightDiff(strings.Join(parts, "\n"), style)}
+    }
+}
+
+// highlightDiff colors a unified diff; it is returned plain if that fails.
+func highlightDiff(src string, style *chroma.Style) string {
+    it, err := lexers.Get("diff").Tokenise(nil, src)
+    if err != nil { return src }
+    var buf bytes.Buffer
+    if err := formatters.TTY16m.Format(&buf, style, it); err != nil { return src }
+    return buf.String()
+}
+
+// diffStyle derives the diff colors from the theme palette.
+func diffStyle(th theme.Theme) *chroma.Style {
+    // themes without a palette get the usual diff colors
+    color := func(key, def string) string {
+        if th.Colors.Normal[key] == "" && th.Colors.Bright[key] == "" { return def }
+        return paletteHex(th, key)
+    }
+    st, err := chroma.NewStyle("workflow", chroma.StyleEntries{
+        chroma.Background:        pickFG(th.Colors, th.Dark),
+        chroma.GenericInserted:   color("green", "#5faf5f"),
+        chroma.GenericDeleted:    color("red", "#d75f5f"),
+        chroma.GenericHeading:    "bold " + pickAccent(th.Colors, th.Dark),
+        chroma.GenericSubheading: color("cyan", "#5fafaf"),
+    })
+    if err != nil { return chroma.MustNewStyle("workflow", chroma.StyleEntries{}) }
+    return st
+}
+
+// openChanges shows the changed files of the current repo.
+func (m *Model) openChanges() tea.Cmd {
+    path := m.currentPath()
+    if path == "" {
+        m.status = "no selection"
+        return nil
+    }
+    m.changesRepo = path
+    m.changeItems = m.setupThemedList(nil, "Changes — "+filepath.Base(path))
+    m.changeItems.SetSize(min(80, m.width-4), min(14, m.height-8))
+    m.showChanges = true
+    m.status = "loading changes…"
+    m.updateTableHeight()
+    return loadChangesCmd(path)
+}
+
+// setChanges fills the list, keeping the cursor in place, and follows the
+// open diff to the file's new status.
+func (m *Model) setChanges(msg changesMsg) tea.Cmd {
+    if !m.showChanges || msg.repo != m.changesRepo { return nil }
+    if msg.err != nil {
+        m.status = "changes: " + msg.err.Error()
+        return nil
+    }
+    if m.status == "loading changes…" { m.status = "" }
+    if len(msg.files) == 0 {
+        m.showChanges, m.showDiff = false, false
+        m.status = "working tree clean"
+        m.updateTableHeight()
+        return nil
+    }
+    idx := m.changeItems.Index()
+    items := make([]list.Item, len(msg.files))
+    for i, f := range msg.files { items[i] = changeItem{f: f} }
+    m.changeItems.SetItems(items)
+    m.changeItems.Select(min(idx, len(items)-1))
+    if !m.showDiff { return nil }
+    for _, f := range msg.files {
+        if f.Path == m.diffFile.Path { return m.openDiff(f) }
+    }
+    m.showDiff = false
+    m.updateTableHeight()
+    return nil
+}
+
+// selectedChange returns the highlighted file of the changes list.
+func (m Model) selectedChange() (scanner.FileChange, bool) {
+    it, ok := m.changeItems.SelectedItem().(changeItem)
+    return it.f, ok
+}
+
+// openDiff shows the diff of f, loading it in the background.
+func (m *Model) openDiff(f scanner.FileChange) tea.Cmd {
+    if !m.showDiff || f.Path != m.diffFile.Path { m.diffView.GotoTop() }
+    m.diffFile = f
+    m.showDiff = true
+    m.diffView.SetContent("loading diff…")
+    m.updateTableHeight()
+    return loadDiffCmd(m.changesRepo, f, diffStyle(m.th))
+}
+
+func (m *Model) setDiff(msg diffMsg) {
+    if !m.showDiff || msg.repo != m.changesRepo || msg.file != m.diffFile.Path { return }
+    if msg.err != nil {
+        m.diffView.SetContent("error: " + msg.err.Error())
+        return
+    }
+    off := m.diffView.YOffset
+    m.diffView.SetContent(msg.text)
+    m.diffView.SetYOffset(off)
+}
+
+// fileOp stages, unstages or (after a y/N question) discards f.
+func (m *Model) fileOp(op string, f scanner.FileChange) tea.Cmd {
+    repo := m.changesRepo
+    run := func(note string, fn func() error) tea.Cmd {
+        return func() tea.Msg { return fileOpMsg{repo: repo, note: note, err: fn()} }
+    }
+    switch op {
+    case "stage":
+        return run("staged "+f.Path, func() error { return gitutil.Stage(repo, f.Path) })
+    case "unstage":
+        if !f.Staged() {
+            m.status = f.Path + " has nothing staged"
+            return nil
+        }
+        return run("unstaged "+f.Path, func() error { return gitutil.Unstage(repo, f.Paths()...) })
+    case "discard":
+        m.ask(fmt.Sprintf("discard all changes to %s?", f.Path), func(m *Model) tea.Cmd {
+            m.status = "discarding " + f.Path + "…"
+            return run("discarded "+f.Path, func() error { return gitutil.Discard(repo, f.Path, f.Orig, f.Untracked, f.X == 'A') })
+        })
+    }
+    return nil
+}
+
+// fileOpDone reports an operation and reloads the list and the repo's row.
+func (m *Model) fileOpDone(msg fileOpMsg) tea.Cmd {
+    if msg.err != nil {
+        m.status = msg.note + ": " + msg.err.Error()
+    } else {
+        m.status = msg.note
+    }
+    cmds := []tea.Cmd{loadChangesCmd(msg.repo)}
+    if i := m.repoIndex(msg.repo); i >= 0 { cmds = append(cmds, refreshRepoCmd(m.repos[i])) }
+    return tea.Batch(cmds...)
+}
+
+// changesKey handles keys while the changes list or a diff is open.
+func (m Model) changesKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+    f, ok := m.selectedChange()
+    if m.showDiff { f, ok = m.diffFile, true }
+    switch msg.String() {
+    case "esc", "q":
+        if m.showDiff {
+            m.showDiff = false
+        } else {
+            m.showChanges = false
+            if m.status == "loading changes…" { m.status = "" }
+        }
+        m.updateTableHeight()
+        return m, nil
+    case "enter":
+        if !m.showDiff && ok { return m, m.openDiff(f) }
+        return m, nil
+    case "s", "u", "x":
+        if !ok { return m, nil }
+        op := map[string]string{"s": "stage", "u": "unstage", "x": "discard"}[msg.String()]
+        return m, m.fileOp(op, f)
+    }
+    var cmd tea.Cmd
+    if m.showDiff {
+        m.diffView, cmd = m.diffView.Update(msg)
+    } else {
+        m.changeItems, cmd = m.changeItems.Update(msg)
+    }
+    return m, cmd
+}
+
+// changesView renders the list or the diff, with the status line below.
+func (m Model) changesView() string {
+    var b strings.Builder
+    if m.showDiff {
+        head := m.diffFile.Path + " — " + describeChange(m.diffFile) + "  (s stage, u unstage, x discard, Esc back)"
+        fmt.Fprintln(&b, selectedStyle.Render(head))
+        fmt.Fprintln(&b, m.diffView.View())
+    } else {
+        fmt.Fprintln(&b, m.changeItems.View())
+        fmt.Fprintln(&b, statusStyle.Faint(true).Render("enter diff  s stage  u unstage  x discard  esc close"))
+    }
+    fmt.Fprint(&b, statusStyle.Render(m.status))
+    return b.String()
+}
@@ -0,0 +1,91 @@
+package ui
+
+import (
+    "io"
+    "os/exec"
+    "path/filepath"
+
+    tea "github.com/charmbracelet/bubbletea"
+    "workflow/internal/mux"
+    "workflow/internal/run"
+)
+
+// execDoneMsg is sent when a program run in place exits and the TUI resumes,
+// or when a multiplexer open has finished.
+type execDoneMsg struct {
+    Path  string
+    Label string
+    Err   error
+    Note  string // status on success
+}
+
+// autoInline reports whether "auto" modes should stay in this terminal:
+// there is no display to open windows on, or no terminal to open them with.
+func (m Model) autoInline() bool {
+    return run.Headless() || !run.TerminalAvailable(m.cfg)
+}
+
+// launchMode resolves a configured mode to "in_place", "new_window" or
+// "mux". "auto" uses the multiplexer the TUI runs in, else runs in place
+// when no window can be opened.
+func (m Model) launchMode(mode string) string {
+    switch mode {
+    case "in_place", "new_window", "mux":
+        return mode
+    case "inline":
+        return "in_place"
+    }
+    if _, ok := mux.Inside(); ok { return "mux" }
+    if m.autoInline() { return "in_place" }
+    return "new_window"
+}
+
+// openApp runs a terminal program for the repo at path: in place (suspending
+// the TUI), in a new window or in tmux/zellij, depending on the mode
+// configured for action. shellCmd is what mux runs; empty opens a shell.
+func (m *Model) openApp(path, action, label, shellCmd string, inPlace func() *exec.Cmd, window func() (*exec.Cmd, error)) tea.Cmd {
+    return m.openAppMode(path, m.cfg.Terminal.ModeFor(action), label, shellCmd, inPlace, window)
+}
+
+// openAppMode is openApp with the mode given directly.
+func (m *Model) openAppMode(path, mode, label, shellCmd string, inPlace func() *exec.Cmd, window func() (*exec.Cmd, error)) tea.Cmd {
+    switch m.launchMode(mode) {
+    case "mux":
+        return m.openMux(path, label, shellCmd)
+    case "in_place":
+        name := filepath.Base(path)
+        if i := m.repoIndex(path); i >= 0 { name = m.repos[i].Name }
+        c := &trackedExec{jobs: m.jobs, path: path, repo: name, label: label, cmd: inPlace()}
+        return tea.Exec(c, func(err error) tea.Msg { return execDoneMsg{Path: path, Label: label, Err: err} })
+    }
+    if err := m.launch(path, label, window); err != nil {
+        m.status = label + ": " + err.Error()
+    } else {
+        m.status = "opened " + label
+    }
+    return nil
+}
+
+// trackedExec is a tea.ExecCommand that records the process in the jobs panel.
+type trackedExec struct {
+    jobs              *jobManager
+    path, repo, label string
+    cmd               *exec.Cmd
+}
+
+func (t *trackedExec) SetStdin(r io.Reader)  { t.cmd.Stdin = r }
+func (t *trackedExec) SetStdout(w io.Writer) { t.cmd.Stdout = w }
+func (t *trackedExec) SetStderr(w io.Writer) { t.cmd.Stderr = w }
+
+func (t *trackedExec) Run() error {
+    // interactive programs can't be re-run in the background
+    j := t.jobs.add(t.path, t.repo, t.label, t.cmd, nil, false)
+    if err := t.cmd.Start(); err != nil {
+        t.jobs.finish(j, err)
+        return err
+    }
+    t.jobs.started(j)
+    err := t.cmd.Wait()
+    t.jobs.finish(j, err)
+    return err
+}
@@ -0,0 +1,159 @@
+package ui
+
+import (
+    "context"
+    "fmt"
+    "os/exec"
+    "sort"
+    "sync"
+    "time"
+
+    tea "github.com/charmbracelet/bubbletea"
+    "workflow/internal/config"
+    "workflow/internal/gitutil"
+    "workflow/internal/scanner"
+)
+
+var fetchArgs = []string{"fetch", "--all", "--prune"}
+
+// fetchBatch tracks one fetch request (a single repo, a selection or every
+// visible row). Counters are only touched from Update.
+type fetchBatch struct {
+    total   int
+    done    int
+    results []bulkResult
+    ch      chan tea.Msg
+}
+
+// fetchedMsg reports one finished fetch; Entry is already rescanned.
+type fetchedMsg struct {
+    Entry scanner.RepoEntry
+    Err   error
+    batch *fetchBatch
+}
+
+// fetchBatchDoneMsg is sent after the last fetchedMsg of a batch.
+type fetchBatchDoneMsg struct{ batch *fetchBatch }
+
+// startFetch fetches entries in the background, skipping repos that are
+// already being fetched, and marks the rest as in flight. Workspace packages
+// share their parent's repo and are skipped when the parent is included.
+func (m *Model) startFetch(entries []scanner.RepoEntry) tea.Cmd {
+    included := make(map[string]bool, len(entries))
+    for _, e := range entries { included[e.Path] = true }
+    todo := make([]scanner.RepoEntry, 0, len(entries))
+    for _, e := range entries {
+        if m.fetching[e.Path] || (e.WorkspacePkg && included[e.ParentPath]) { continue }
+        m.fetching[e.Path] = true
+        todo = append(todo, e)
+    }
+    if len(todo) == 0 {
+        m.status = "fetch already running"
+        return nil
+    }
+    if len(todo) == 1 {
+        m.status = "fetching " + todo[0].Name + "…"
+    } else {
+        m.status = fmt.Sprintf("fetching 0/%d…", len(todo))
+    }
+    m.refreshRows()
+    b := &fetchBatch{total: len(todo), ch: make(chan tea.Msg, len(todo)+1)}
+    return fetchCmd(m.cfg, m.jobs, todo, b)
+}
+
+// fetchCmd runs git fetch with the configured concurrency and timeout and
+// streams a fetchedMsg per repo.
+func fetchCmd(cfg config.Config, jobs *jobManager, entries []scanner.RepoEntry, b *fetchBatch) tea.Cmd {
+    return func() tea.Msg {
+        go func() {
+            limit := cfg.Git.Jobs
+            if limit <= 0 { limit = bulkConcurrency }
+            timeout := time.Duration(cfg.Git.TimeoutSeconds) * time.Second
+            var wg sync.WaitGroup
+            sem := make(chan struct{}, limit)
+            for _, e := range entries {
+                e := e
+                wg.Add(1)
+                sem <- struct{}{}
+                go func() {
+                    defer wg.Done()
+                    defer func() { <-sem }()
+                    ctx, cancel := context.Background(), func() {}
+                    if timeout > 0 { ctx, cancel = context.WithTimeout(ctx, timeout) }
+                    out, err := jobs.run(e.Path, e.Name, "fetch", backgroundGit(ctx, e.Path, fetchArgs...), rerunGit(e.Path, fetchArgs...), timeout)
+                    err = gitutil.CommandError(ctx, out, err)
+                    cancel()
+                    b.ch <- fetchedMsg{Entry: scanner.Refresh(e), Err: err, batch: b}
+                }()
+            }
+            wg.Wait()
+            b.ch <- fetchBatchDoneMsg{batch: b}
+            close(b.ch)
+        }()
+        return <-b.ch
+    }
+}
+
+// backgroundGit builds a git command for a job: it runs without the
+// terminal, so credential prompts fail instead of waiting.
+func backgroundGit(ctx context.Context, path string, args ...string) *exec.Cmd {
+    return gitutil.Batch(gitutil.Command(ctx, path, args...))
+}
+
+// rerunGit builds the git command again when its job is re-run; the job
+// manager applies the timeout.
+func rerunGit(path string, args ...string) func() (*exec.Cmd, error) {
+    return func() (*exec.Cmd, error) { return backgroundGit(context.Background(), path, args...), nil }
+}
+
+// fetchWaitCmd reads the next message of a fetch batch.
+func fetchWaitCmd(b *fetchBatch) tea.Cmd {
+    return func() tea.Msg {
+        msg, ok := <-b.ch
+        if !ok { return nil }
+        return msg
+    }
+}
+
+// handleFetched updates the row and the progress of its batch.
+func (m *Model) handleFetched(msg fetchedMsg) tea.Cmd {
+    b := msg.batch
+    b.done++
+    delete(m.fetching, msg.Entry.Path)
+    r := bulkResult{Name: msg.Entry.Name, Path: msg.Entry.Path, OK: msg.Err == nil}
+    if msg.Err != nil { r.Msg = msg.Err.Error() }
+    b.results = append(b.results, r)
+    // the repo may have been dropped by a rescan in the meantime
+    if m.repoIndex(msg.Entry.Path) >= 0 {
+        m.upsertRepo(msg.Entry)
+        m.repos = orderRepos(m.repos, m.sortKey, m.sortAsc)
+    }
+    m.refreshRows()
+    if b.total > 1 { m.status = fmt.Sprintf("fetching %d/%d…", b.done, b.total) }
+    return fetchWaitCmd(b)
+}
+
+// finishFetch reports a completed batch: the status line for a single repo
+// or a clean run, the results panel when several repos were fetched and some
+// failed.
+func (m *Model) finishFetch(b *fetchBatch) {
+    failed := 0
+    for _, r := range b.results {
+        if !r.OK { failed++ }
+    }
+    switch {
+    case b.total == 1 && failed == 0:
+        m.status = "fetched " + b.results[0].Name
+    case b.total == 1:
+        m.status = "fetch " + b.results[0].Name + ": " + b.results[0].Msg
+    case failed == 0:
+        m.status = fmt.Sprintf("fetched %d repos", b.total)
+    default:
+        // keep the table order rather than completion order
+        order := make(map[string]int, len(m.visible))
+        for row, ri := range m.visible { order[m.repos[ri].Path] = row }
+        res := append([]bulkResult(nil), b.results...)
+        sort.SliceStable(res, func(i, j int) bool { return order[res[i].Path] < order[res[j].Path] })
+        m.showBulkResults("fetch", res)
+    }
+}
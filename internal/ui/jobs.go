@@ -0,0 +1,416 @@
+package ui
+
+import (
+    "bytes"
+    "fmt"
+    "io"
+    "os/exec"
+    "path/filepath"
+    "slices"
+    "strings"
+    "sync"
+    "syscall"
+    "time"
+
+    tea "github.com/charmbracelet/bubbletea"
+    "github.com/charmbracelet/lipgloss"
+    "github.com/mattn/go-runewidth"
+)
+
+// jobOutputMax bounds the output kept per job; older bytes are dropped.
+const jobOutputMax = 64 << 10
+
+// jobsKeep is how many finished jobs stay listed; running ones always do.
+const jobsKeep = 50
+
+// job is one process spawned by the UI. Fields are guarded by the manager.
+type job struct {
+    id     int
+    repo   string // display name
+    path   string
+    label  string // what was run, e.g. "fetch" or "task test"
+    args   []string
+    start  time.Time
+    end    time.Time
+    pid    int
+    exit   int
+    err    string
+    killed bool
+    async  bool // started with start(), reported via jobDoneMsg
+    out    *ringBuffer
+    cmd    *exec.Cmd
+    build  func() (*exec.Cmd, error) // re-run
+    // timeout bounds re-runs like the first run; expired is set when the
+    // job was killed for running past it
+    timeout time.Duration
+    expired bool
+}
+
+// jobInfo is a copy of a job's state for rendering.
+type jobInfo struct {
+    ID      int
+    Repo    string
+    Path    string
+    Label   string
+    Args    []string
+    Start   time.Time
+    End     time.Time
+    PID     int
+    Exit    int
+    Err     string
+    Killed  bool
+    Running bool
+    Async   bool
+}
+
+// jobManager records every process the UI spawns so it can be listed,
+// tailed, killed and re-run.
+type jobManager struct {
+    mu   sync.Mutex
+    jobs []*job
+    next int
+    done chan jobInfo
+}
+
+type jobDoneMsg struct{ Job jobInfo }
+type jobsTickMsg struct{}
+
+func newJobManager() *jobManager {
+    return &jobManager{done: make(chan jobInfo, 64)}
+}
+
+// start launches the command from build in the background. Windows such as
+// terminals and editors stay listed as running until they are closed.
+func (jm *jobManager) start(path, repo, label string, build func() (*exec.Cmd, error)) error {
+    return jm.startTimed(path, repo, label, build, 0)
+}
+
+// startTimed is start with the job killed after timeout, if positive. The
+// timer is stopped as soon as the job ends.
+func (jm *jobManager) startTimed(path, repo, label string, build func() (*exec.Cmd, error), timeout time.Duration) error {
+    cmd, err := build()
+    if err != nil { return err }
+    ownGroup(cmd)
+    j := jm.add(path, repo, label, cmd, build, true)
+    j.timeout = timeout
+    if err := cmd.Start(); err != nil {
+        jm.finish(j, err)
+        return err
+    }
+    jm.started(j)
+    stop := func() bool { return false }
+    if timeout > 0 { stop = time.AfterFunc(timeout, func() { jm.expire(j) }).Stop }
+    go func() {
+        err := cmd.Wait()
+        stop()
+        jm.finish(j, err)
+    }()
+    return nil
+}
+
+// expire kills a job that ran past its timeout.
+func (jm *jobManager) expire(j *job) {
+    jm.mu.Lock()
+    defer jm.mu.Unlock()
+    if !j.end.IsZero() { return }
+    j.expired = true
+    killGroup(j.cmd)
+}
+
+// run executes cmd to completion and returns its combined output. rerun
+// builds a fresh command when the job is re-run from the panel, bounded by
+// timeout; cmd itself is bounded by the caller's context.
+func (jm *jobManager) run(path, repo, label string, cmd *exec.Cmd, rerun func() (*exec.Cmd, error), timeout time.Duration) ([]byte, error) {
+    ownGroup(cmd)
+    j := jm.add(path, repo, label, cmd, rerun, false)
+    j.timeout = timeout
+    var buf bytes.Buffer
+    cmd.Stdout = io.MultiWriter(&buf, j.out)
+    cmd.Stderr = cmd.Stdout
+    if err := cmd.Start(); err != nil {
+        jm.finish(j, err)
+        return nil, err
+    }
+    jm.started(j)
+    err := cmd.Wait()
+    jm.finish(j, err)
+    return buf.Bytes(), err
+}
+
+func (jm *jobManager) add(path, repo, label string, cmd *exec.Cmd, build func() (*exec.Cmd, error), async bool) *job {
+    j := &job{path: path, repo: repo, label: label, args: cmd.Args, out: newRingBuffer(jobOutputMax),
+        cmd: cmd, build: build, async: async, exit: -1}
+    if cmd.Stdout == nil { cmd.Stdout = j.out }
+    if cmd.Stderr == nil { cmd.Stderr = j.out }
+    jm.mu.Lock()
+    jm.next++
+    j.id = jm.next
+    j.start = time.Now()
+    jm.jobs = append(jm.jobs, j)
+    jm.mu.Unlock()
+    return j
+}
+
+func (jm *jobManager) started(j *job) {
+    jm.mu.Lock()
+    j.pid = j.cmd.Process.Pid
+    jm.mu.Unlock()
+}
+
+func (jm *jobManager) finish(j *job, err error) {
+    jm.mu.Lock()
+    j.end = time.Now()
+    if j.cmd.ProcessState != nil { j.exit = j.cmd.ProcessState.ExitCode() }
+    if j.expired {
+        j.err = "timed out"
+    } else if err != nil && j.exit <= 0 && !j.killed {
+        j.err = err.Error()
+    }
+    info := j.info()
+    jm.prune()
+    jm.mu.Unlock()
+    jm.done <- info
+}
+
+// prune drops the oldest finished jobs beyond jobsKeep. The caller holds mu.
+func (jm *jobManager) prune() {
+    finished := 0
+    keep := jm.jobs[:0]
+    for i := len(jm.jobs) - 1; i >= 0; i-- {
+        j := jm.jobs[i]
+        if !j.end.IsZero() {
+            finished++
+            if finished > jobsKeep { continue }
+        }
+        keep = append(keep, j)
+    }
+    slices.Reverse(keep)
+    clear(jm.jobs[len(keep):])
+    jm.jobs = keep
+}
+
+func (j *job) info() jobInfo {
+    return jobInfo{
+        ID: j.id, Repo: j.repo, Path: j.path, Label: j.label, Args: j.args,
+        Start: j.start, End: j.end, PID: j.pid, Exit: j.exit, Err: j.err,
+        Killed: j.killed, Running: j.end.IsZero(), Async: j.async,
+    }
+}
+
+// list returns the jobs, newest first.
+func (jm *jobManager) list() []jobInfo {
+    jm.mu.Lock()
+    defer jm.mu.Unlock()
+    out := make([]jobInfo, 0, len(jm.jobs))
+    for i := len(jm.jobs) - 1; i >= 0; i-- { out = append(out, jm.jobs[i].info()) }
+    return out
+}
+
+// running returns the jobs still in flight.
+func (jm *jobManager) running() []jobInfo {
+    var out []jobInfo
+    for _, j := range jm.list() {
+        if j.Running { out = append(out, j) }
+    }
+    return out
+}
+
+func (jm *jobManager) find(id int) *job {
+    jm.mu.Lock()
+    defer jm.mu.Unlock()
+    for _, j := range jm.jobs {
+        if j.id == id { return j }
+    }
+    return nil
+}
+
+// output returns the captured output of job id.
+func (jm *jobManager) output(id int) string {
+    j := jm.find(id)
+    if j == nil { return "" }
+    return j.out.String()
+}
+
+func (jm *jobManager) kill(id int) error {
+    j := jm.find(id)
+    if j == nil { return fmt.Errorf("no job #%d", id) }
+    jm.mu.Lock()
+    defer jm.mu.Unlock()
+    if !j.end.IsZero() || j.cmd.Process == nil { return fmt.Errorf("job #%d is not running", id) }
+    j.killed = true
+    return killGroup(j.cmd)
+}
+
+// ownGroup starts cmd in a session of its own, so a kill reaches its
+// children too. The session has no controlling terminal: a prompt that
+// opens /dev/tty fails instead of stopping the job for good on SIGTTIN.
+// Only for background jobs: programs run in place must stay in the
+// terminal's foreground group.
+func ownGroup(cmd *exec.Cmd) {
+    if cmd.SysProcAttr == nil { cmd.SysProcAttr = &syscall.SysProcAttr{} }
+    cmd.SysProcAttr.Setsid = true
+    // a timed-out git would otherwise leave its ssh/https helpers holding
+    // the output pipe
+    if cmd.Cancel != nil { cmd.Cancel = func() error { return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL) } }
+}
+
+// killGroup kills the process group cmd leads, or just the process when it
+// shares the UI's group.
+func killGroup(cmd *exec.Cmd) error {
+    if a := cmd.SysProcAttr; a != nil && (a.Setpgid || a.Setsid) { return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL) }
+    return cmd.Process.Kill()
+}
+
+// rerun starts job id again as a new background job.
+func (jm *jobManager) rerun(id int) error {
+    j := jm.find(id)
+    if j == nil { return fmt.Errorf("no job #%d", id) }
+    if j.build == nil { return fmt.Errorf("job #%d cannot be re-run", id) }
+    return jm.startTimed(j.path, j.repo, j.label, j.build, j.timeout)
+}
+
+// jobsWaitCmd waits for the next finished job.
+func jobsWaitCmd(jm *jobManager) tea.Cmd {
+    return func() tea.Msg { return jobDoneMsg{Job: <-jm.done} }
+}
+
+// jobsTickCmd re-renders the open jobs panel so output tails stay current.
+func jobsTickCmd() tea.Cmd {
+    return tea.Tick(time.Second, func(time.Time) tea.Msg { return jobsTickMsg{} })
+}
+
+// startTicker starts the once-a-second re-render unless it is running.
+func (m *Model) startTicker() tea.Cmd {
+    if m.ticking { return nil }
+    m.ticking = true
+    return jobsTickCmd()
+}
+
+// needsTick reports whether an open panel shows running times.
+func (m Model) needsTick() bool {
+    return m.showJobs || (m.showRun && m.taskRun != nil && m.taskRun.running())
+}
+
+// launch runs build as a tracked background job for the repo at path.
+func (m *Model) launch(path, label string, build func() (*exec.Cmd, error)) error {
+    name := filepath.Base(path)
+    if i := m.repoIndex(path); i >= 0 { name = m.repos[i].Name }
+    return m.jobs.start(path, name, label, build)
+}
+
+// currentJob returns the job under the panel cursor.
+func (m Model) currentJob() (jobInfo, bool) {
+    js := m.jobs.list()
+    if len(js) == 0 { return jobInfo{}, false }
+    return js[min(m.jobsCursor, len(js)-1)], true
+}
+
+// jobsPanelHeight is the number of lines the jobs panel takes out of contentH.
+func (m Model) jobsPanelHeight(contentH int) int {
+    return max(6, min(18, contentH/2))
+}
+
+// jobsPanelView renders the job list with the output tail of the job under
+// the cursor, in height lines.
+func (m Model) jobsPanelView(height int) string {
+    js := m.jobs.list()
+    accent := lipgloss.Color(pickAccent(m.th.Colors, m.th.Dark))
+    head := lipgloss.NewStyle().Bold(true).Foreground(accent)
+    running := 0
+    for _, j := range js {
+        if j.Running { running++ }
+    }
+    var lines []string
+    lines = append(lines, head.Render(fmt.Sprintf("Jobs: %d running, %d total  (j/k move  x kill  r re-run  Esc close)", running, len(js))))
+    if len(js) == 0 {
+        lines = append(lines, "no jobs yet")
+        return strings.Join(lines, "\n")
+    }
+    cur := min(m.jobsCursor, len(js)-1)
+    listH := min(len(js), max(3, height/3))
+    first := max(0, min(cur-listH/2, len(js)-listH))
+    for i := first; i < first+listH; i++ {
+        j := js[i]
+        mark := "  "
+        if i == cur { mark = head.Render("> ") }
+        lines = append(lines, mark+m.jobLine(j))
+    }
+    sel := js[cur]
+    lines = append(lines, headerStyle.Faint(true).Render(fmt.Sprintf("── #%d %s", sel.ID, strings.Join(sel.Args, " "))))
+    tailH := height - len(lines)
+    if tailH > 0 {
+        out := strings.TrimRight(m.jobs.output(sel.ID), "\n")
+        if out == "" {
+            lines = append(lines, statusStyle.Render("(no output)"))
+        } else {
+            tail := strings.Split(out, "\n")
+            if len(tail) > tailH { tail = tail[len(tail)-tailH:] }
+            w := max(20, m.width-2)
+            for _, ln := range tail {
+                // drop carriage-return progress updates, keep the last state
+                if k := strings.LastIndex(ln, "\r"); k >= 0 { ln = ln[k+1:] }
+                ln = runewidth.Truncate(ln, w, "")
+                lines = append(lines, ln)
+            }
+        }
+    }
+    return strings.Join(lines, "\n")
+}
+
+func (m Model) jobLine(j jobInfo) string {
+    state, color := "ok", "green"
+    switch {
+    case j.Running:
+        state, color = "running", "yellow"
+    case j.Killed:
+        state, color = "killed", "magenta"
+    case j.Err != "":
+        state, color = "error", "red"
+    case j.Exit != 0:
+        state, color = fmt.Sprintf("exit %d", j.Exit), "red"
+    }
+    end := j.End
+    if j.Running { end = time.Now() }
+    line := fmt.Sprintf("#%-3d %s %-20s %-24s %7s  pid %d", j.ID, colorBadge(fmt.Sprintf("%-8s", state), m.th, color),
+        runewidth.Truncate(j.Repo, 20, "…"), runewidth.Truncate(j.Label, 24, "…"), end.Sub(j.Start).Round(time.Second), j.PID)
+    if j.Err != "" { line += "  " + j.Err }
+    return line
+}
+
+// runningJobsSummary describes running jobs for the quit warning.
+func runningJobsSummary(js []jobInfo) string {
+    names := make([]string, 0, len(js))
+    for i, j := range js {
+        if i == 3 {
+            names = append(names, fmt.Sprintf("+%d more", len(js)-3))
+            break
+        }
+        names = append(names, j.Label+" in "+j.Repo)
+    }
+    return strings.Join(names, ", ")
+}
+
+// ringBuffer keeps the last max bytes written to it.
+type ringBuffer struct {
+    mu  sync.Mutex
+    buf []byte
+    max int
+}
+
+func newRingBuffer(max int) *ringBuffer { return &ringBuffer{max: max} }
+
+func (r *ringBuffer) Write(p []byte) (int, error) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    r.buf = append(r.buf, p...)
+    if over := len(r.buf) - r.max; over > 0 {
+        r.buf = append(r.buf[:0], r.buf[over:]...)
+    }
+    return len(p), nil
+}
+
+func (r *ringBuffer) String() string {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    return string(r.buf)
+}
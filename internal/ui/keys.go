@@ -0,0 +1,53 @@
+package ui
+
+import (
+    "strings"
+
+    "github.com/charmbracelet/bubbles/key"
+    "github.com/charmbracelet/bubbles/table"
+    "github.com/mattn/go-runewidth"
+    "workflow/internal/config"
+)
+
+// tableKeyMap moves the table cursor with config.Navigation only; the
+// table's other default keys (b, f, space, u, d) belong to actions.
+func tableKeyMap() table.KeyMap {
+    km := table.DefaultKeyMap()
+    // in the order of config.Navigation
+    bs := []*key.Binding{&km.LineUp, &km.LineDown, &km.PageUp, &km.PageDown, &km.HalfPageUp, &km.HalfPageDown, &km.GotoTop, &km.GotoBottom}
+    for i, n := range config.Navigation { bs[i].SetKeys(n.Keys...) }
+    return km
+}
+
+// keyName is how a key is shown in the help footer.
+func keyName(k string) string {
+    if k == " " { return "space" }
+    return k
+}
+
+// helpLines renders the help footer from the active keymap, wrapped to the
+// terminal width.
+func (m Model) helpLines() []string {
+    // table navigation is not remappable
+    items := []string{"j/k move", "g/G home/end"}
+    for _, b := range config.Bindings {
+        ks := m.cfg.Keys[b.Action]
+        if len(ks) == 0 { continue }
+        names := make([]string, len(ks))
+        for i, k := range ks { names[i] = keyName(k) }
+        items = append(items, strings.Join(names, "/")+" "+b.Help)
+    }
+    width := max(40, m.width)
+    var lines []string
+    cur := ""
+    for _, it := range items {
+        if cur != "" && runewidth.StringWidth(cur)+2+runewidth.StringWidth(it) > width {
+            lines = append(lines, cur)
+            cur = ""
+        }
+        if cur != "" { cur += "  " }
+        cur += it
+    }
+    if cur != "" { lines = append(lines, cur) }
+    return lines
+}
@@ -0,0 +1,258 @@
+package ui
+
+import (
+    "fmt"
+    "os"
+    "os/exec"
+    "path/filepath"
+    "regexp"
+    "strings"
+
+    tea "github.com/charmbracelet/bubbletea"
+    "github.com/charmbracelet/glamour"
+    "github.com/charmbracelet/glamour/ansi"
+    "github.com/charmbracelet/glamour/styles"
+    xansi "github.com/charmbracelet/x/ansi"
+    "workflow/internal/run"
+    "workflow/internal/shell"
+    "workflow/internal/theme"
+)
+
+// mdKey identifies a rendered document: a file changes key when it's
+// modified or the viewer is resized.
+type mdKey struct {
+    path  string
+    mtime int64
+    width int
+}
+
+// mdHeading is a heading of a rendered document and the line it starts on.
+type mdHeading struct {
+    line  int
+    level int
+    text  string
+}
+
+type mdRendered struct {
+    out      string
+    headings []mdHeading
+}
+
+type mdRenderedMsg struct {
+    key mdKey
+    doc mdRendered
+    err error
+}
+
+// mdCacheMax bounds the render cache; it is dropped whole when full.
+const mdCacheMax = 32
+
+// markdownStyle derives glamour's style from the theme palette.
+func markdownStyle(th theme.Theme) ansi.StyleConfig {
+    st := styles.LightStyleConfig
+    if th.Dark { st = styles.DarkStyleConfig }
+    p := th.Colors
+    set := func(dst **string, v string) { if v != "" { *dst = &v } }
+    fg := pickFG(p, th.Dark)
+    acc := pickAccent(p, th.Dark)
+    set(&st.Document.Color, fg)
+    set(&st.Heading.Color, acc)
+    set(&st.H1.Color, bestTextFor(acc, fg, pickBG(p, th.Dark)))
+    set(&st.H1.BackgroundColor, acc)
+    set(&st.H6.Color, p.Normal["magenta"])
+    set(&st.Link.Color, p.Normal["cyan"])
+    set(&st.LinkText.Color, p.Normal["magenta"])
+    set(&st.Image.Color, p.Normal["magenta"])
+    set(&st.Code.Color, p.Normal["red"])
+    set(&st.HorizontalRule.Color, p.Bright["black"])
+    return st
+}
+
+// renderMarkdown renders src wrapped to width.
+func renderMarkdown(src string, style ansi.StyleConfig, width int) (string, error) {
+    r, err := glamour.NewTermRenderer(glamour.WithStyles(style), glamour.WithWordWrap(width))
+    if err != nil { return "", err }
+    return r.Render(src)
+}
+
+// renderMarkdownCmd reads and renders the file of key in the background.
+func renderMarkdownCmd(key mdKey, style ansi.StyleConfig) tea.Cmd {
+    return func() tea.Msg {
+        src, err := os.ReadFile(key.path)
+        if err != nil { return mdRenderedMsg{key: key, err: err} }
+        out, err := renderMarkdown(string(src), style, key.width)
+        if err != nil { return mdRenderedMsg{key: key, err: err} }
+        return mdRenderedMsg{key: key, doc: mdRendered{out: out, headings: locateHeadings(string(src), out)}}
+    }
+}
+
+var (
+    atxHeading = regexp.MustCompile(`^ {0,3}(#{1,6})\s+(.*?)(\s+#+)?\s*$`)
+    mdLink     = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+)
+
+// locateHeadings finds the ATX headings of src outside code fences and the
+// rendered lines they ended up on. Headings that can't be found are left out.
+func locateHeadings(src, out string) []mdHeading {
+    lines := strings.Split(xansi.Strip(out), "\n")
+    var hs []mdHeading
+    fence := ""
+    next := 0
+    for _, ln := range strings.Split(src, "\n") {
+        t := strings.TrimSpace(ln)
+        if fence != "" {
+            if strings.HasPrefix(t, fence) { fence = "" }
+            continue
+        }
+        if strings.HasPrefix(t, "```") || strings.HasPrefix(t, "~~~") {
+            fence = t[:3]
+            continue
+        }
+        sub := atxHeading.FindStringSubmatch(ln)
+        if sub == nil { continue }
+        text := plainHeading(sub[2])
+        if text == "" { continue }
+        // long headings wrap; their start is enough to find them
+        probe := text
+        if r := []rune(probe); len(r) > 24 { probe = string(r[:24]) }
+        for i := next; i < len(lines); i++ {
+            if strings.Contains(lines[i], probe) {
+                hs = append(hs, mdHeading{line: i, level: len(sub[1]), text: text})
+                next = i + 1
+                break
+            }
+        }
+    }
+    return hs
+}
+
+// plainHeading drops inline markup from heading text.
+func plainHeading(s string) string {
+    s = mdLink.ReplaceAllString(s, "$1")
+    s = strings.NewReplacer("`", "", "**", "", "__", "").Replace(s)
+    return strings.TrimSpace(s)
+}
+
+// pagerCmd shows a file in bat, or less when bat isn't installed.
+func pagerCmd(file string) string {
+    q := shell.Quote(file)
+    return "if command -v bat >/dev/null 2>&1; then bat --style=plain --decorations=never --paging=always --color=always " + q + "; " +
+        "elif command -v batcat >/dev/null 2>&1; then batcat --style=plain --decorations=never --paging=always --color=always " + q + "; " +
+        "else less " + q + "; fi"
+}
+
+// mdWidth is the wrap width of the markdown viewer.
+func (m Model) mdWidth() int { return max(20, m.width-2) }
+
+// openMarkdown shows file of the repo at path in the markdown viewer.
+func (m *Model) openMarkdown(path, file string) tea.Cmd {
+    abs := filepath.Join(path, file)
+    fi, err := os.Stat(abs)
+    if err != nil {
+        m.status = "view: " + err.Error()
+        return nil
+    }
+    m.mdRepo, m.mdName = path, file
+    m.mdKey = mdKey{path: abs, mtime: fi.ModTime().UnixNano(), width: m.mdWidth()}
+    m.showMdView = true
+    m.updateTableHeight()
+    m.mdView.GotoTop()
+    return m.loadMarkdown()
+}
+
+// loadMarkdown puts the document of m.mdKey on screen, rendering it in the
+// background when it isn't cached.
+func (m *Model) loadMarkdown() tea.Cmd {
+    if doc, ok := m.mdCache[m.mdKey]; ok {
+        m.setMarkdown(doc)
+        return nil
+    }
+    m.mdHeadings = nil
+    m.mdView.SetContent("rendering…")
+    return renderMarkdownCmd(m.mdKey, markdownStyle(m.th))
+}
+
+func (m *Model) setMarkdown(doc mdRendered) {
+    off := m.mdView.YOffset
+    m.mdView.SetContent(doc.out)
+    m.mdView.SetYOffset(off)
+    m.mdHeadings = doc.headings
+}
+
+// handleMarkdownRendered caches a finished render and shows it if the
+// viewer is still waiting for it.
+func (m *Model) handleMarkdownRendered(msg mdRenderedMsg) {
+    current := m.showMdView && msg.key == m.mdKey
+    if msg.err != nil {
+        if current { m.mdView.SetContent("error: " + msg.err.Error()) }
+        return
+    }
+    if len(m.mdCache) >= mdCacheMax { m.mdCache = map[mdKey]mdRendered{} }
+    m.mdCache[msg.key] = msg.doc
+    if current { m.setMarkdown(msg.doc) }
+}
+
+// mdHeadingAt returns the index of the heading the viewer is scrolled to.
+func (m Model) mdHeadingAt() int {
+    cur := -1
+    for i, h := range m.mdHeadings {
+        if h.line > m.mdView.YOffset { break }
+        cur = i
+    }
+    return cur
+}
+
+// jumpHeading scrolls to the next (dir > 0) or previous heading.
+func (m *Model) jumpHeading(dir int) {
+    off := m.mdView.YOffset
+    if dir > 0 {
+        for _, h := range m.mdHeadings {
+            if h.line > off { m.mdView.SetYOffset(h.line); return }
+        }
+        return
+    }
+    for i := len(m.mdHeadings) - 1; i >= 0; i-- {
+        if h := m.mdHeadings[i]; h.line < off { m.mdView.SetYOffset(h.line); return }
+    }
+    m.mdView.GotoTop()
+}
+
+// markdownKey handles keys while the markdown viewer is open.
+func (m Model) markdownKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+    switch msg.String() {
+    case "esc", "q":
+        m.showMdView = false
+        m.updateTableHeight()
+        return m, nil
+    case "]", "n":
+        m.jumpHeading(1)
+        return m, nil
+    case "[", "N":
+        m.jumpHeading(-1)
+        return m, nil
+    case "g", "home":
+        m.mdView.GotoTop()
+        return m, nil
+    case "G", "end":
+        m.mdView.GotoBottom()
+        return m, nil
+    case "o":
+        // the full-screen pager, as before the viewer existed
+        path, cmd := m.mdRepo, pagerCmd(m.mdKey.path)
+        return m, m.openApp(path, "pager", "view "+m.mdName, cmd,
+            func() *exec.Cmd { return run.InPlaceCmd(path, cmd) },
+            func() (*exec.Cmd, error) { return run.ShellCmd(path, cmd, m.cfg) })
+    }
+    var cmd tea.Cmd
+    m.mdView, cmd = m.mdView.Update(msg)
+    return m, cmd
+}
+
+// markdownHeader is the line above the viewer: file, current heading and keys.
+func (m Model) markdownHeader() string {
+    head := m.mdName
+    if i := m.mdHeadingAt(); i >= 0 {
+        head += fmt.Sprintf(" — %s (%d/%d)", m.mdHeadings[i].text, i+1, len(m.mdHeadings))
+    }
+    return head + fmt.Sprintf("  %d%%  ([/] headings, o pager, Esc close)", int(m.mdView.ScrollPercent()*100))
+}
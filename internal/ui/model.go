@@ -1,9 +1,12 @@
 package ui
 
 import (
+    "context"
     "fmt"
-    "path/filepath"
+    "math"
     "os/exec"
+    "regexp"
+    "slices"
     "sort"
     "strings"
     "time"
@@ -14,11 +17,16 @@ import (
     "github.com/charmbracelet/bubbles/list"
     "github.com/charmbracelet/bubbles/spinner"
     "github.com/charmbracelet/bubbles/viewport"
+    "github.com/charmbracelet/glamour/ansi"
     "github.com/charmbracelet/lipgloss"
+    "workflow/internal/agents"
     "workflow/internal/config"
+    "workflow/internal/mux"
     "workflow/internal/run"
     "workflow/internal/scanner"
+    "workflow/internal/shell"
     "workflow/internal/gitutil"
+    "workflow/internal/query"
     "workflow/internal/theme"
     "workflow/internal/tasks"
 )
@@ -32,6 +40,10 @@ type Model struct {
     reposLoaded bool
     repos       []scanner.RepoEntry
     filter      string
+    query       *query.Query // compiled filter; nil matches everything
+    // filter applied before / was pressed, restored on Esc
+    prevFilter string
+    prevQuery  *query.Query
     visible     []int // mapping of table row -> repos index
 
     // Config + theme
@@ -57,6 +69,8 @@ type Model struct {
 
     // theme watch
     themeWatch bool
+    // repo watch (live status updates)
+    repoWatch *repoWatcher
     // Grouping
     grouped  bool
     expanded map[string]bool // parent path -> expanded
@@ -64,15 +78,83 @@ type Model struct {
     showTasks bool
     taskItems list.Model
     curTasks  []tasks.Task
+    // Command palette (ctrl+p)
+    showPalette bool
+    paletteList list.Model
+    // Action picker (:)
+    showActions bool
+    actionItems list.Model
+    // y/N question in the status line
+    confirm *pendingConfirm
     // Markdown files overlay
     showMarkdown bool
     markdownItems list.Model
     markdownFiles []string
+    // Markdown viewer and its render cache
+    showMdView bool
+    mdView     viewport.Model
+    mdRepo     string
+    mdName     string
+    mdKey      mdKey
+    mdHeadings []mdHeading
+    mdCache    map[mdKey]mdRendered
+    // Changed files (c) of changesRepo and the diff of one of them
+    showChanges bool
+    changeItems list.Model
+    changesRepo string
+    showDiff    bool
+    diffView    viewport.Model
+    diffFile    scanner.FileChange
+    // Branches (b) of branchRepo compared to branchBase; branchPrompt is
+    // "create" or "rename" while a name is typed into branchInput
+    showBranches bool
+    branchItems  list.Model
+    branchRepo   string
+    branchBase   string
+    branchInput  textinput.Model
+    branchPrompt string
+    // Preview pane beside the table on wide terminals; previewWant is the
+    // previewKey being shown or loaded, previewSeq debounces loads
+    preview     viewport.Model
+    previewWant string
+    previewSeq  int
+    // Key-bound actions of the highlighted repo, resolved as the cursor moves
+    repoKeys repoKeys
+    // Multi-select (repo path -> selected) and bulk results panel
+    selected     map[string]bool
+    showResults  bool
+    results      viewport.Model
+    resultsTitle string
+    // repos the task picker runs in when opened for a selection
+    taskTargets []scanner.RepoEntry
+    // repos with a fetch in flight (path -> true)
+    fetching map[string]bool
+    // Spawned processes and the jobs panel
+    jobs       *jobManager
+    showJobs   bool
+    jobsCursor int
+    // key -> action name, from cfg.Keys
+    keys map[string]string
+    // q pressed once while jobs were still running
+    quitArmed bool
+    // once-a-second re-render for panels showing running times
+    ticking bool
+    // Inline task run (pty) and its output pane
+    taskRun   *taskRun
+    showRun   bool
+    runView   viewport.Model
+    runFollow bool // keep scrolled to the bottom as output arrives
+    // tmux/zellij backend, if one is available, and its live sessions
+    muxer    mux.Mux
+    hasMux   bool
+    sessions mux.Sessions
     // Scan busy state
     scanning bool
 
     // Spinner for scanning
     spin spinner.Model
+    // Streaming scan progress (entries collected / known so far)
+    scanProgress scanner.Progress
 
     // Last scan stats
     scanStart   time.Time
@@ -91,11 +173,11 @@ func NewModel(cfg config.Config, th theme.Theme) Model {
         {Title: "A/B", Width: 5},
         {Title: "Last", Width: 6},
     }
-    t := table.New(table.WithColumns(columns), table.WithHeight(12))
+    t := table.New(table.WithColumns(columns), table.WithHeight(12), table.WithKeyMap(tableKeyMap()))
     t.Focus()
     ti := textinput.New()
-    ti.Placeholder = "type to filter; Enter apply, Esc cancel"
-    ti.CharLimit = 64
+    ti.Placeholder = "text or query: dirty or ahead -tag:archived; Enter apply, Esc cancel"
+    ti.CharLimit = 256
     m := Model{
         showHelp:    true,
         reposLoaded: false,
@@ -113,6 +195,10 @@ func NewModel(cfg config.Config, th theme.Theme) Model {
         sortAsc:     false,
         grouped:     true,
         expanded:    map[string]bool{},
+        selected:    map[string]bool{},
+        fetching:    map[string]bool{},
+        jobs:        newJobManager(),
+        repoWatch:   newRepoWatcher(),
     }
     // Initialize monorepo parents expanded (grouped view is default)
     // Spinner init
@@ -127,28 +213,65 @@ func NewModel(cfg config.Config, th theme.Theme) Model {
     vp := viewport.New(60, 12)
     vp.SetContent("")
     m.detail = vp
+    m.results = viewport.New(60, 10)
+    m.runView = viewport.New(60, 10)
+    m.mdView = viewport.New(60, 10)
+    m.preview = viewport.New(40, 10)
+    m.diffView = viewport.New(60, 10)
+    m.muxer, m.hasMux = mux.Detect(cfg.Mux.Backend)
+    m.keys = map[string]string{}
+    for action, ks := range cfg.Keys {
+        for _, k := range ks { m.keys[k] = action }
+    }
     return m
 }
 
 func (m Model) Init() tea.Cmd {
     // Start async scan and theme watch (Omarchy)
-    return tea.Batch(
-        func() tea.Msg { return startScanMsg{} },
+    // Cached rows first so the table is populated before the scan starts
+    cmds := []tea.Cmd{
+        tea.Sequence(loadCachedCmd(m.cfg), func() tea.Msg { return startScanMsg{} }),
         themeWatchStartCmd(),
         themeWatchWaitCmd(),
-    )
+        repoWatchWaitCmd(m.repoWatch),
+        jobsWaitCmd(m.jobs),
+    }
+    if m.hasMux { cmds = append(cmds, muxListCmd(m.muxer, 0, true)) }
+    return tea.Batch(cmds...)
 }
 
 type repoListMsg struct{ Entries []scanner.RepoEntry }
+type scanEntryMsg struct {
+    Entry    scanner.RepoEntry
+    Progress scanner.Progress
+    ch       chan tea.Msg
+}
 type detailMsg struct{ Text string }
 type startScanMsg struct{}
+type cachedReposMsg struct{ Entries []scanner.RepoEntry }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+    next, cmd := m.update(msg)
+    nm, ok := next.(Model)
+    if !ok { return next, cmd }
+    nm.syncRepoKeys()
+    // the preview follows the cursor and the highlighted repo's status
+    if c := nm.schedulePreview(); c != nil { cmd = tea.Batch(cmd, c) }
+    return nm, cmd
+}
+
+func (m Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
     switch msg := msg.(type) {
     case spinner.TickMsg:
         var cmd tea.Cmd
         m.spin, cmd = m.spin.Update(msg)
         return m, cmd
+    case list.FilterMatchesMsg:
+        // fuzzy matches of the palette filter arrive asynchronously
+        if !m.showPalette { return m, nil }
+        var cmd tea.Cmd
+        m.paletteList, cmd = m.paletteList.Update(msg)
+        return m, cmd
     case tea.WindowSizeMsg:
         m.width = msg.Width
         m.height = msg.Height
@@ -162,9 +285,30 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
         if m.showMarkdown {
             m.markdownItems.SetSize(min(60, m.width-4), min(12, m.height-6))
         }
+        if m.showActions {
+            m.actionItems.SetSize(min(60, m.width-4), min(12, m.height-6))
+        }
+        if m.showPalette {
+            m.paletteList.SetSize(min(80, m.width-4), min(16, m.height-6))
+        }
+        if m.showChanges {
+            m.changeItems.SetSize(min(80, m.width-4), min(14, m.height-8))
+        }
+        if m.showBranches {
+            m.branchItems.SetSize(min(80, m.width-4), min(14, m.height-8))
+        }
         // Use near full width for details to maximize readability
         if m.width > 4 { m.detail.Width = m.width - 2 } else { m.detail.Width = m.width }
         m.detail.Height = min(m.height-8, 20)
+        m.results.Width = m.detail.Width
+        m.runView.Width = m.width
+        m.mdView.Width = m.width
+        m.diffView.Width = m.width
+        m.resizeTaskPty()
+        if m.showMdView && m.mdKey.width != m.mdWidth() {
+            m.mdKey.width = m.mdWidth()
+            return m, m.loadMarkdown()
+        }
         return m, nil
 
     case repoListMsg:
@@ -176,12 +320,118 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
         if !m.scanStart.IsZero() { m.lastScanDur = time.Since(m.scanStart) }
         m.lastRepoCnt = len(m.repos)
         m.lastRootsCnt = len(m.cfg.Roots)
+        paths := make([]string, 0, len(m.repos))
+        for _, r := range m.repos { paths = append(paths, r.Path) }
+        m.repoWatch.Sync(paths)
+        return m, nil
+    case bulkDoneMsg:
+        if len(msg.Results) == 1 && len(m.selected) == 0 {
+            // single repo: the status line is enough
+            r := msg.Results[0]
+            if r.OK {
+                m.status = msg.Title + ": done"
+                if r.Msg != "" { m.status = msg.Title + ": " + r.Msg }
+            } else {
+                m.status = msg.Title + ": " + r.Msg
+            }
+        } else {
+            m.showBulkResults(msg.Title, msg.Results)
+        }
+        cmds := make([]tea.Cmd, 0, len(msg.Entries))
+        for _, e := range msg.Entries { cmds = append(cmds, refreshRepoCmd(e)) }
+        return m, tea.Batch(cmds...)
+    case jobDoneMsg:
+        j := msg.Job
+        next := jobsWaitCmd(m.jobs)
+        if !j.Async { return m, next }
+        // background jobs report failures here; windows closing normally stay quiet
+        if j.Err != "" {
+            m.status = fmt.Sprintf("job #%d %s (%s): %s", j.ID, j.Label, j.Repo, j.Err)
+        } else if j.Exit > 0 {
+            m.status = fmt.Sprintf("job #%d %s (%s) exited %d", j.ID, j.Label, j.Repo, j.Exit)
+        }
+        if i := m.repoIndex(j.Path); i >= 0 {
+            return m, tea.Batch(next, refreshRepoCmd(m.repos[i]))
+        }
+        return m, next
+    case execDoneMsg:
+        if msg.Err != nil {
+            m.status = msg.Label + ": " + msg.Err.Error()
+        } else {
+            m.status = msg.Note
+        }
+        var cmds []tea.Cmd
+        // editors and lazygit usually change the repo
+        if i := m.repoIndex(msg.Path); i >= 0 { cmds = append(cmds, refreshRepoCmd(m.repos[i])) }
+        if m.hasMux { cmds = append(cmds, muxListCmd(m.muxer, 0, false)) }
+        return m, tea.Batch(cmds...)
+    case muxSessionsMsg:
+        m.sessions = msg.sessions
+        m.refreshRows()
+        if msg.poll { return m, muxListCmd(m.muxer, muxPollInterval, true) }
+        return m, nil
+    case jobsTickMsg:
+        if !m.needsTick() { m.ticking = false; return m, nil }
+        return m, jobsTickCmd()
+    case taskOutputMsg:
+        if msg.run == m.taskRun { m.refreshTaskView() }
+        return m, taskWaitCmd(msg.run)
+    case taskExitMsg:
+        r := msg.run
+        r.end, r.exit = time.Now(), msg.exit
+        if msg.err != nil { r.err = msg.err.Error() }
+        if r != m.taskRun { return m, nil }
+        m.refreshTaskView()
+        if r.exit == 0 && r.err == "" {
+            m.status = fmt.Sprintf("%s finished in %s", r.task.Name, r.end.Sub(r.start).Round(10*time.Millisecond))
+        } else {
+            m.status = fmt.Sprintf("%s failed (exit %d)", r.task.Name, r.exit)
+        }
+        // tasks (formatters, generators) often touch the working tree
+        if i := m.repoIndex(r.path); i >= 0 { return m, refreshRepoCmd(m.repos[i]) }
+        return m, nil
+    case fetchedMsg:
+        return m, m.handleFetched(msg)
+    case fetchBatchDoneMsg:
+        m.finishFetch(msg.batch)
+        return m, nil
+    case repoChangedMsg:
+        next := repoWatchWaitCmd(m.repoWatch)
+        if i := m.repoIndex(msg.Path); i >= 0 {
+            return m, tea.Batch(refreshRepoCmd(m.repos[i]), next)
+        }
+        return m, next
+    case repoUpdatedMsg:
+        // ignore results for repos dropped by a rescan in the meantime
+        if m.repoIndex(msg.Entry.Path) < 0 { return m, nil }
+        m.upsertRepo(msg.Entry)
+        m.repos = orderRepos(m.repos, m.sortKey, m.sortAsc)
+        m.refreshRows()
         return m, nil
+    case cachedReposMsg:
+        if len(msg.Entries) == 0 { return m, nil }
+        for _, e := range msg.Entries {
+            // never replace a row that was already rescanned
+            if m.repoIndex(e.Path) < 0 { m.repos = append(m.repos, e) }
+        }
+        m.reposLoaded = true
+        m.repos = orderRepos(m.repos, m.sortKey, m.sortAsc)
+        m.refreshRows()
+        return m, nil
+    case scanEntryMsg:
+        // Rows are added (or refreshed in place) as repos finish scanning
+        m.reposLoaded = true
+        m.upsertRepo(msg.Entry)
+        m.repos = orderRepos(m.repos, m.sortKey, m.sortAsc)
+        m.scanProgress = msg.Progress
+        m.refreshRows()
+        return m, scanWaitCmd(msg.ch)
     case startScanMsg:
         if m.scanning { return m, nil }
         m.scanning = true
         m.status = "scanning…"
         m.scanStart = time.Now()
+        m.scanProgress = scanner.Progress{}
         return m, tea.Batch(scanCmd(m.cfg), m.spin.Tick)
     case themeTickMsg:
         // If theme changed, reapply palette
@@ -190,24 +440,151 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
             m.applyThemeToUI()
             m.setupAgentsList()
             m.updateTableHeader()
+            if m.showMdView { return m, tea.Batch(themeWatchWaitCmd(), m.loadMarkdown()) }
         }
         // Wait for next change
         return m, themeWatchWaitCmd()
+    case previewDueMsg:
+        return m, m.loadPreview(msg)
+    case previewMsg:
+        m.setPreview(msg)
+        return m, nil
+    case changesMsg:
+        return m, m.setChanges(msg)
+    case diffMsg:
+        m.setDiff(msg)
+        return m, nil
+    case fileOpMsg:
+        return m, m.fileOpDone(msg)
+    case branchesMsg:
+        m.setBranches(msg)
+        return m, nil
+    case branchOpMsg:
+        return m, m.branchOpDone(msg)
+    case mdRenderedMsg:
+        m.handleMarkdownRendered(msg)
+        return m, nil
     case detailMsg:
-        // Apply some minimal section styling on the first lines (plain)
-        lines := strings.Split(msg.Text, "\n")
-        if len(lines) > 0 {
-            lines[0] = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(pickAccent(m.th.Colors, m.th.Dark))).Render(lines[0])
-        }
-        for i, ln := range lines {
-            if ln == "Tasks (press r to run)" || ln == "Recent commits" || ln == "Docs (press d)" {
-                lines[i] = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(pickAccent(m.th.Colors, m.th.Dark))).Render(ln)
-            }
-        }
-        m.detail.SetContent(strings.Join(lines, "\n"))
+        m.detail.SetContent(m.styleDetail(msg.Text))
         return m, nil
 
     case tea.KeyMsg:
+        armed := m.quitArmed
+        m.quitArmed = false
+        if c := m.confirm; c != nil {
+            m.confirm = nil
+            if k := msg.String(); k == "y" || k == "Y" { return m, c.onYes(&m) }
+            m.status = "cancelled"
+            return m, nil
+        }
+        if m.showRun {
+            switch msg.String() {
+            case "ctrl+c":
+                m.interruptTask()
+                return m, nil
+            case "esc", "q":
+                // the task keeps running; t reopens the pane
+                m.showRun = false
+                m.updateTableHeight()
+                return m, nil
+            case "r":
+                return m, tea.Batch(m.rerunTask(), m.startTicker())
+            case "G", "end":
+                m.runFollow = true
+                m.runView.GotoBottom()
+                return m, nil
+            }
+            var cmd tea.Cmd
+            m.runView, cmd = m.runView.Update(msg)
+            m.runFollow = m.runView.AtBottom()
+            return m, cmd
+        }
+        if m.showJobs {
+            n := len(m.jobs.list())
+            switch msg.String() {
+            case "esc", "q", "J":
+                m.showJobs = false
+                m.updateTableHeight()
+                return m, nil
+            case "j", "down":
+                if m.jobsCursor < n-1 { m.jobsCursor++ }
+                return m, nil
+            case "k", "up":
+                if m.jobsCursor > 0 { m.jobsCursor-- }
+                return m, nil
+            case "x":
+                if j, ok := m.currentJob(); ok {
+                    if err := m.jobs.kill(j.ID); err != nil {
+                        m.status = "kill: " + err.Error()
+                    } else {
+                        m.status = fmt.Sprintf("killed job #%d", j.ID)
+                    }
+                }
+                return m, nil
+            case "r":
+                if j, ok := m.currentJob(); ok {
+                    if err := m.jobs.rerun(j.ID); err != nil {
+                        m.status = "re-run: " + err.Error()
+                    } else {
+                        m.status = fmt.Sprintf("re-ran job #%d", j.ID)
+                        m.jobsCursor = 0
+                    }
+                }
+                return m, nil
+            }
+            return m, nil
+        }
+        if m.showResults {
+            switch msg.String() {
+            case "esc", "q", "enter":
+                m.showResults = false
+                m.updateTableHeight()
+                return m, nil
+            case "j":
+                m.results.ScrollDown(1)
+                return m, nil
+            case "k":
+                m.results.ScrollUp(1)
+                return m, nil
+            }
+            var cmd tea.Cmd
+            m.results, cmd = m.results.Update(msg)
+            return m, cmd
+        }
+        if m.showPalette {
+            switch msg.String() {
+            case "esc", "ctrl+p":
+                m.showPalette = false
+                m.updateTableHeight()
+                return m, nil
+            case "enter":
+                it, ok := m.paletteList.SelectedItem().(paletteItem)
+                m.showPalette = false
+                m.updateTableHeight()
+                if !ok { return m, nil }
+                return it.run(m)
+            }
+            var cmd tea.Cmd
+            m.paletteList, cmd = m.paletteList.Update(msg)
+            return m, cmd
+        }
+        if m.showActions {
+            switch msg.String() {
+            case "esc", "q":
+                m.showActions = false
+                m.updateTableHeight()
+                return m, nil
+            case "enter":
+                it, ok := m.actionItems.SelectedItem().(actionItem)
+                m.showActions = false
+                m.updateTableHeight()
+                if !ok { return m, nil }
+                return m, m.triggerAction(it.a)
+            }
+            var cmd tea.Cmd
+            m.actionItems, cmd = m.actionItems.Update(msg)
+            return m, cmd
+        }
         if m.showTasks {
             switch msg.String() {
             case "esc", "q":
@@ -216,23 +593,44 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
                 return m, nil
             case "enter":
                 idx := m.taskItems.Index()
+                mode := m.taskMode()
+                if idx >= 0 && idx < len(m.curTasks) && len(m.taskTargets) > 0 {
+                    // picker opened for a selection: run the task by name in each repo
+                    name := m.curTasks[idx].Name
+                    m.showTasks = false
+                    m.updateTableHeight()
+                    return m, runEach("task "+name, m.taskTargets, func(e scanner.RepoEntry) (string, error) {
+                        t, ok := findTask(e.Path, name)
+                        if !ok { return "", fmt.Errorf("no task %q", name) }
+                        switch mode {
+                        case "inline":
+                            // one pane can't show several runs; output is in the jobs panel
+                            return "started (J for output)", m.launch(e.Path, "task "+name, func() (*exec.Cmd, error) {
+                                return inlineTaskCmd(e.Path, t), nil
+                            })
+                        case "mux":
+                            return m.startMux(e.Path, "task "+name, t.Shell())
+                        }
+                        return "launched " + t.Cmd, m.launch(e.Path, "task "+name, func() (*exec.Cmd, error) {
+                            return run.ShellCmd(e.Path, t.Shell(), m.cfg)
+                        })
+                    })
+                }
                 if idx >= 0 && idx < len(m.curTasks) {
                     path := m.currentPath()
-                    if path == "" { m.showTasks = false; return m, nil }
-                    cmdStr := m.curTasks[idx].Cmd
-                    if err := run.LaunchShellCmdNewWindow(path, cmdStr, m.cfg); err != nil {
-                        m.status = "task: " + err.Error()
-                    } else {
-                        m.status = "task launched: " + m.curTasks[idx].Name
-                    }
                     m.showTasks = false
-                    return m, nil
+                    m.updateTableHeight()
+                    if path == "" { return m, nil }
+                    return m, m.runTask(path, m.curTasks[idx])
                 }
             }
             var cmd tea.Cmd
             m.taskItems, cmd = m.taskItems.Update(msg)
             return m, cmd
         }
+        if m.showChanges { return m.changesKey(msg) }
+        if m.showBranches { return m.branchesKey(msg) }
+        if m.showMdView { return m.markdownKey(msg) }
         if m.showMarkdown {
             switch msg.String() {
             case "esc", "q":
@@ -247,18 +645,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
                         m.showMarkdown = false
                         return m, nil
                     }
-                    file := m.markdownFiles[idx]
-                    filePath := filepath.Join(path, file)
-                    cmd := "if command -v bat >/dev/null 2>&1; then bat --style=plain --decorations=never --paging=always --color=always '" + filePath + "'; " +
-                        "elif command -v batcat >/dev/null 2>&1; then batcat --style=plain --decorations=never --paging=always --color=always '" + filePath + "'; " +
-                        "else less '" + filePath + "'; fi"
-                    if err := run.LaunchShellCmdNewWindow(path, cmd, m.cfg); err != nil {
-                        m.status = "markdown: " + err.Error()
-                    } else {
-                        m.status = "opened " + file
-                    }
                     m.showMarkdown = false
-                    return m, nil
+                    return m, m.openMarkdown(path, m.markdownFiles[idx])
                 }
             }
             var cmd tea.Cmd
@@ -276,6 +664,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
                 m.openMarkdownPicker()
                 m.showDetail = false
                 return m, nil
+            case "c":
+                m.showDetail = false
+                return m, m.openChanges()
             case "j":
                 m.detail.ScrollDown(1)
                 return m, nil
@@ -301,16 +692,24 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
                 m.updateTableHeight()
                 return m, nil
             case "enter":
+                if it, ok := m.agents.SelectedItem().(agentItem); ok && len(m.selected) > 0 {
+                    m.showAgents = false
+                    m.updateTableHeight()
+                    muxed := m.launchMode(m.cfg.Terminal.ModeFor("agent")) == "mux"
+                    return m, runEach("agent "+it.name, m.targets(), func(e scanner.RepoEntry) (string, error) {
+                        cfg := m.agentCfg(e.Path)
+                        if muxed { return m.startMux(e.Path, "agent "+it.name, agents.BuildAgentCommand(it.name, e.Path, cfg)) }
+                        return "launched", m.launch(e.Path, "agent "+it.name, func() (*exec.Cmd, error) {
+                            return run.AgentCmd(e.Path, it.name, cfg)
+                        })
+                    })
+                }
                 if it, ok := m.agents.SelectedItem().(agentItem); ok {
                     path := m.currentPath()
                     if path == "" { m.status = "no selection"; m.showAgents = false; return m, nil }
-                    if err := run.LaunchAgentNewWindow(path, it.name, m.cfg); err != nil {
-                        m.status = "agent: " + err.Error()
-                    } else {
-                        m.status = "agent launched: " + it.name
-                    }
                     m.showAgents = false
-                    return m, nil
+                    m.updateTableHeight()
+                    return m, m.openAgent(path, it.name)
                 }
             }
             var cmd tea.Cmd
@@ -320,12 +719,22 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
         if m.filtering {
             switch msg.Type {
             case tea.KeyEsc:
+                // rows were filtered live; restore what was applied before /
                 m.filtering = false
                 m.input.Blur()
+                m.filter, m.query = m.prevFilter, m.prevQuery
+                m.refreshRows()
                 m.status = "filter canceled"
                 return m, nil
             case tea.KeyEnter:
+                q, err := query.Parse(m.input.Value())
+                if err != nil {
+                    // keep the input open so the query can be fixed
+                    m.status = "filter: " + err.Error()
+                    return m, nil
+                }
                 m.filter = m.input.Value()
+                m.query = q
                 m.filtering = false
                 m.input.Blur()
                 m.status = "filter applied"
@@ -333,192 +742,356 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
                 return m, nil
             }
             var cmd tea.Cmd
+            prev := m.input.Value()
             m.input, cmd = m.input.Update(msg)
+            if v := m.input.Value(); v != prev {
+                // Filter incrementally; an incomplete query keeps the last valid one
+                if q, err := query.Parse(v); err != nil {
+                    m.status = "filter: " + err.Error()
+                } else {
+                    m.filter, m.query = v, q
+                    m.status = "type to filter; Enter apply; Esc cancel"
+                    m.table.SetCursor(0)
+                    m.refreshRows()
+                }
+            }
             return m, cmd
         }
-        k := msg.String()
-        // Map configured keys to internal defaults for switch handling
-        k = m.mapKey(k)
-        switch k {
-        case "ctrl+c", "q":
-            return m, tea.Quit
-        case "?":
-            m.showHelp = !m.showHelp
-            return m, nil
-          case "x":
-            // Expand/collapse parent group
-            if len(m.visible) == 0 { return m, nil }
-            idx := m.table.Cursor()
-            if idx < 0 || idx >= len(m.visible) { return m, nil }
-            ri := m.visible[idx]
-            if ri < 0 || ri >= len(m.repos) { return m, nil }
-            r := m.repos[ri]
-            var parent string
-            if r.WorkspacePkg {
-                parent = r.ParentPath
-            } else if r.Monorepo {
-                parent = r.Path
-            }
-            if parent != "" {
-                m.expanded[parent] = !m.expanded[parent]
-                m.refreshRows()
-                return m, nil
-            }
-            return m, nil
-        case "enter":
-            // Open detail overlay and load content
-            if len(m.visible) == 0 { return m, nil }
-            idx := m.table.Cursor()
-            if idx < 0 || idx >= len(m.visible) { return m, nil }
-            ri := m.visible[idx]
-            if ri < 0 || ri >= len(m.repos) { return m, nil }
-            repo := m.repos[ri]
-            m.showDetail = true
-            m.status = ""
-            m.updateTableHeight()
-            return m, loadDetailCmd(repo)
-        case "d":
-            // Open markdown files picker
-            m.openMarkdownPicker()
-            return m, nil
-        case "/":
-            m.filtering = true
-            m.input.SetValue(m.filter)
-            m.input.Focus()
-            m.status = "type to filter; Enter apply; Esc cancel"
-            return m, nil
-        case "R":
-            if m.scanning { m.status = "already scanning"; return m, nil }
-            m.status = "refreshing…"
-            return m, tea.Batch(scanCmd(m.cfg), m.spin.Tick)
-        case "r":
-            // Open tasks picker for current repo
-            path := m.currentPath()
-            if path == "" { return m, nil }
-            ts := tasks.Detect(path)
-            m.curTasks = ts
-            items := make([]list.Item, 0, len(ts))
-            for _, tsk := range ts {
-                items = append(items, taskItem{Task: tsk})
-            }
-            if len(items) == 0 {
-                m.status = "no tasks detected"
-                return m, nil
-            }
-            m.taskItems = m.setupThemedList(items, "Run task")
-            m.showTasks = true
+        return m.handleKey(m.keys[msg.String()], msg, armed)
+    }
+    return m, nil
+}
+
+// handleKey runs the table action bound to a key. Keys bound to no action go
+// to user actions and then the table; the palette calls it with the action
+// it picked.
+func (m Model) handleKey(action string, msg tea.KeyMsg, armed bool) (tea.Model, tea.Cmd) {
+    k := msg.String()
+    switch action {
+    case "quit":
+        if rs := m.jobs.running(); len(rs) > 0 && !armed {
+            m.quitArmed = true
+            again := k
+            // from the palette there is no key; name the first bound one
+            if ks := m.cfg.Keys["quit"]; again == "" && len(ks) > 0 { again = keyName(ks[0]) }
+            m.status = fmt.Sprintf("%d jobs still running (%s); press %s again to quit, J to view", len(rs), runningJobsSummary(rs), again)
             return m, nil
-        case "s":
-            // Cycle sort key: last -> ab -> branch -> last
-            switch m.sortKey {
-            case "last":
-                m.sortKey = "ab"
-            case "ab":
-                m.sortKey = "branch"
-            default:
-                m.sortKey = "last"
-            }
-            m.repos = orderRepos(m.repos, m.sortKey, m.sortAsc)
+        }
+        return m, tea.Quit
+    case "jobs":
+        m.showJobs = true
+        m.jobsCursor = 0
+        m.updateTableHeight()
+        return m, m.startTicker()
+    case "task_output":
+        // reopen the inline task pane
+        if m.taskRun == nil { m.status = "no inline task yet"; return m, nil }
+        m.showRun = true
+        m.refreshTaskView()
+        m.updateTableHeight()
+        return m, m.startTicker()
+    case "help":
+        m.showHelp = !m.showHelp
+        m.updateTableHeight()
+        return m, nil
+    case "palette":
+        m.openPalette()
+        m.updateTableHeight()
+        return m, nil
+    case "actions":
+        m.openActionPicker()
+        m.updateTableHeight()
+        return m, nil
+    case "select":
+        // toggle selection and advance so runs of rows are quick to pick
+        m.toggleSelected()
+        m.table.MoveDown(1)
+        m.refreshRows()
+        m.status = fmt.Sprintf("%d selected", len(m.selected))
+        return m, nil
+    case "select_all":
+        m.selectAllVisible()
+        m.refreshRows()
+        m.status = fmt.Sprintf("%d selected", len(m.selected))
+        return m, nil
+    case "invert":
+        m.invertVisible()
+        m.refreshRows()
+        m.status = fmt.Sprintf("%d selected", len(m.selected))
+        return m, nil
+    case "clear":
+        if len(m.selected) > 0 {
+            m.selected = map[string]bool{}
             m.refreshRows()
-            m.status = "sort: " + m.sortKey + map[bool]string{true:" asc", false:" desc"}[m.sortAsc]
-            return m, nil
-        case "S":
-            m.sortAsc = !m.sortAsc
-            m.repos = orderRepos(m.repos, m.sortKey, m.sortAsc)
+            m.status = "selection cleared"
+        }
+        return m, nil
+    case "expand":
+        // Expand/collapse parent group
+        if len(m.visible) == 0 { return m, nil }
+        idx := m.table.Cursor()
+        if idx < 0 || idx >= len(m.visible) { return m, nil }
+        ri := m.visible[idx]
+        if ri < 0 || ri >= len(m.repos) { return m, nil }
+        r := m.repos[ri]
+        var parent string
+        if r.WorkspacePkg {
+            parent = r.ParentPath
+        } else if r.Monorepo {
+            parent = r.Path
+        }
+        if parent != "" {
+            m.expanded[parent] = !m.expanded[parent]
             m.refreshRows()
-            m.status = "sort: " + m.sortKey + map[bool]string{true:" asc", false:" desc"}[m.sortAsc]
-            return m, nil
-        case "e":
-            path := m.currentPath()
-            if path == "" { m.status = "no selection"; return m, nil }
-            ed := m.cfg.Editor.Default
-            if ed == "" { ed = "nvim" }
-            if err := run.LaunchShellCmdNewWindow(path, ed+" "+path, m.cfg); err != nil {
-                m.status = "editor: " + err.Error()
-            } else {
-                m.status = "opened editor"
-            }
-            return m, nil
-        case "E":
-            path := m.currentPath()
-            if path == "" { m.status = "no selection"; return m, nil }
-            if err := run.OpenGUIEditor(path, m.cfg); err != nil {
-                m.status = "GUI editor: " + err.Error()
-            } else {
-                m.status = "opened GUI editor"
-            }
-            return m, nil
-        case "o":
-            path := m.currentPath()
-            if path == "" { m.status = "no selection"; return m, nil }
-            if err := run.OpenTerminalNewWindow(path, m.cfg); err != nil {
-                m.status = "terminal: " + err.Error()
-            } else {
-                m.status = "opened terminal"
-            }
-            return m, nil
-        case "l":
-            path := m.currentPath()
-            if path == "" { m.status = "no selection"; return m, nil }
-            if err := run.LaunchShellCmdNewWindow(path, "lazygit", m.cfg); err != nil {
-                m.status = "lazygit: " + err.Error()
-            } else {
-                m.status = "lazygit launched"
-            }
-            return m, nil
-        case "f":
-            path := m.currentPath()
-            if path == "" { m.status = "no selection"; return m, nil }
-            m.status = "fetching…"
-            go func() { _ = exec.Command("git", "-C", path, "fetch", "--all", "--prune").Run() }()
             return m, nil
-        case "y":
-            // copy path
-            p := m.currentPath()
-            if p == "" { m.status = "no selection"; return m, nil }
-            if err := copyClipboard(p); err != nil { m.status = "copy: " + err.Error() } else { m.status = "copied path" }
-            return m, nil
-        case "u":
-            // open remote URL
-            p := m.currentPath()
-            if p == "" { m.status = "no selection"; return m, nil }
-            url, err := gitutil.RemoteURL(p)
-            if err != nil || url == "" { m.status = "no remote"; return m, nil }
-            _ = exec.Command("xdg-open", url).Start()
-            m.status = "opened remote"
-            return m, nil
-        case "Y":
-            // copy remote URL
-            p := m.currentPath()
-            if p == "" { m.status = "no selection"; return m, nil }
-            url, err := gitutil.RemoteURL(p)
-            if err != nil || url == "" { m.status = "no remote"; return m, nil }
-            if err := copyClipboard(url); err != nil { m.status = "copy: " + err.Error() } else { m.status = "copied URL" }
+        }
+        return m, nil
+    case "details":
+        // Open detail overlay and load content
+        if len(m.visible) == 0 { return m, nil }
+        idx := m.table.Cursor()
+        if idx < 0 || idx >= len(m.visible) { return m, nil }
+        ri := m.visible[idx]
+        if ri < 0 || ri >= len(m.repos) { return m, nil }
+        repo := m.repos[ri]
+        m.showDetail = true
+        m.status = ""
+        m.updateTableHeight()
+        return m, loadDetailCmd(repo, markdownStyle(m.th), m.detail.Width)
+    case "changes":
+        return m, m.openChanges()
+    case "branches":
+        return m, m.openBranches()
+    case "docs":
+        // Open markdown files picker
+        m.openMarkdownPicker()
+        return m, nil
+    case "filter":
+        m.filtering = true
+        m.prevFilter, m.prevQuery = m.filter, m.query
+        m.input.SetValue(m.filter)
+        m.input.Focus()
+        m.status = "type to filter; Enter apply; Esc cancel"
+        return m, nil
+    case "refresh":
+        if m.scanning { m.status = "already scanning"; return m, nil }
+        return m, func() tea.Msg { return startScanMsg{} }
+    case "tasks":
+        if len(m.selected) > 0 {
+            // Tasks picker across the selection, listed by name
+            ts, items := bulkTaskItems(m.targets())
+            if len(items) == 0 { m.status = "no tasks detected"; return m, nil }
+            m.curTasks = ts
+            m.taskTargets = m.targets()
+            m.taskItems = m.setupThemedList(items, fmt.Sprintf("Run task in %d repos", len(m.taskTargets)))
+            m.showTasks = true
             return m, nil
-        case "a":
-            m.showAgents = true
-            // Rebuild items in case config changed while running
-            m.agents.SetItems(m.agentItems())
-            m.status = ""
+        }
+        m.taskTargets = nil
+        // Open tasks picker for current repo
+        path := m.currentPath()
+        if path == "" { return m, nil }
+        ts, err := repoTasks(path)
+        if err != nil { m.status = err.Error() }
+        m.curTasks = ts
+        items := make([]list.Item, 0, len(ts))
+        for _, tsk := range ts {
+            items = append(items, taskItem{Task: tsk})
+        }
+        if len(items) == 0 {
+            m.status = "no tasks detected"
             return m, nil
-        case "A":
-            path := m.currentPath()
-            if path == "" { m.status = "no selection"; return m, nil }
-            agent := m.cfg.Agents.Default
-            if agent == "" { agent = "claude" }
-            if err := run.LaunchAgentNewWindow(path, agent, m.cfg); err != nil {
-                m.status = "agent: " + err.Error()
+        }
+        m.taskItems = m.setupThemedList(items, "Run task")
+        m.showTasks = true
+        return m, nil
+    case "sort":
+        // Cycle sort key: last -> ab -> branch -> last
+        switch m.sortKey {
+        case "last":
+            m.sortKey = "ab"
+        case "ab":
+            m.sortKey = "branch"
+        default:
+            m.sortKey = "last"
+        }
+        m.repos = orderRepos(m.repos, m.sortKey, m.sortAsc)
+        m.refreshRows()
+        m.status = "sort: " + m.sortKey + map[bool]string{true:" asc", false:" desc"}[m.sortAsc]
+        return m, nil
+    case "sort_reverse":
+        m.sortAsc = !m.sortAsc
+        m.repos = orderRepos(m.repos, m.sortKey, m.sortAsc)
+        m.refreshRows()
+        m.status = "sort: " + m.sortKey + map[bool]string{true:" asc", false:" desc"}[m.sortAsc]
+        return m, nil
+    case "editor":
+        ed := m.cfg.Editor.Default
+        if ed == "" { ed = "nvim" }
+        // the editor setting may carry flags; only the path is quoted
+        edCmd := func(p string) string { return ed + " " + shell.Quote(p) }
+        if len(m.selected) > 0 {
+            muxed := m.launchMode(m.cfg.Terminal.ModeFor("editor")) == "mux"
+            return m, runEach("editor", m.targets(), func(e scanner.RepoEntry) (string, error) {
+                if muxed { return m.startMux(e.Path, "editor", edCmd(e.Path)) }
+                return "opened", m.launch(e.Path, "editor", func() (*exec.Cmd, error) {
+                    return run.ShellCmd(e.Path, edCmd(e.Path), m.cfg)
+                })
+            })
+        }
+        path := m.currentPath()
+        if path == "" { m.status = "no selection"; return m, nil }
+        return m, m.openApp(path, "editor", "editor", edCmd(path),
+            func() *exec.Cmd { return run.InPlaceCmd(path, edCmd(path)) },
+            func() (*exec.Cmd, error) { return run.ShellCmd(path, edCmd(path), m.cfg) })
+    case "gui_editor":
+        if len(m.selected) > 0 {
+            return m, runEach("GUI editor", m.targets(), func(e scanner.RepoEntry) (string, error) {
+                return "opened", m.launch(e.Path, "GUI editor", func() (*exec.Cmd, error) {
+                    return run.GUIEditorCmd(e.Path, m.cfg)
+                })
+            })
+        }
+        path := m.currentPath()
+        if path == "" { m.status = "no selection"; return m, nil }
+        err := m.launch(path, "GUI editor", func() (*exec.Cmd, error) {
+            return run.GUIEditorCmd(path, m.cfg)
+        })
+        if err != nil {
+            m.status = "GUI editor: " + err.Error()
+        } else {
+            m.status = "opened GUI editor"
+        }
+        return m, nil
+    case "shell":
+        path := m.currentPath()
+        if path == "" { m.status = "no selection"; return m, nil }
+        return m, m.openApp(path, "shell", "shell", "",
+            func() *exec.Cmd { return run.InPlaceShell(path, m.cfg) },
+            func() (*exec.Cmd, error) { return run.TerminalCmd(path, m.cfg) })
+    case "lazygit":
+        path := m.currentPath()
+        if path == "" { m.status = "no selection"; return m, nil }
+        return m, m.openApp(path, "lazygit", "lazygit", "lazygit",
+            func() *exec.Cmd { return run.InPlaceCmd(path, "lazygit") },
+            func() (*exec.Cmd, error) { return run.ShellCmd(path, "lazygit", m.cfg) })
+    case "fetch":
+        ts := m.targets()
+        if len(ts) == 0 { m.status = "no selection"; return m, nil }
+        return m, m.startFetch(ts)
+    case "fetch_all":
+        // Fetch every visible row
+        ts := make([]scanner.RepoEntry, 0, len(m.visible))
+        for _, ri := range m.visible { ts = append(ts, m.repos[ri]) }
+        if len(ts) == 0 { m.status = "nothing to fetch"; return m, nil }
+        return m, m.startFetch(ts)
+    case "pull":
+        ts := m.targets()
+        if len(ts) == 0 { m.status = "no selection"; return m, nil }
+        m.status = fmt.Sprintf("pulling %d repos…", len(ts))
+        jobs, timeout := m.jobs, time.Duration(m.cfg.Git.TimeoutSeconds)*time.Second
+        return m, bulkCmd("pull --ff-only", ts, func(e scanner.RepoEntry) (string, error) {
+            ctx, cancel := context.Background(), func() {}
+            if timeout > 0 { ctx, cancel = context.WithTimeout(ctx, timeout) }
+            defer cancel()
+            out, err := jobs.run(e.Path, e.Name, "pull --ff-only", backgroundGit(ctx, e.Path, "pull", "--ff-only"), rerunGit(e.Path, "pull", "--ff-only"), timeout)
+            return "", gitutil.CommandError(ctx, out, err)
+        })
+    case "sync":
+        // Sync: the selection, or every visible row
+        ts := m.targets()
+        if len(m.selected) == 0 {
+            ts = ts[:0]
+            for _, ri := range m.visible { ts = append(ts, m.repos[ri]) }
+        }
+        if len(ts) == 0 { m.status = "nothing to sync"; return m, nil }
+        m.status = fmt.Sprintf("syncing %d repos…", len(ts))
+        return m, syncCmd(m.cfg, m.jobs, ts)
+    case "copy_path":
+        if len(m.selected) > 0 {
+            // copy all selected paths, one per line
+            ts := m.targets()
+            paths := make([]string, 0, len(ts))
+            for _, e := range ts { paths = append(paths, e.Path) }
+            if err := copyClipboard(strings.Join(paths, "\n")); err != nil {
+                m.status = "copy: " + err.Error()
             } else {
-                m.status = "agent launched"
+                m.status = fmt.Sprintf("copied %d paths", len(paths))
             }
             return m, nil
-        default:
-            var cmd tea.Cmd
-            m.table, cmd = m.table.Update(msg)
-            return m, cmd
         }
+        // copy path
+        p := m.currentPath()
+        if p == "" { m.status = "no selection"; return m, nil }
+        if err := copyClipboard(p); err != nil { m.status = "copy: " + err.Error() } else { m.status = "copied path" }
+        return m, nil
+    case "open_url":
+        // open remote URL
+        p := m.currentPath()
+        if p == "" { m.status = "no selection"; return m, nil }
+        url, err := gitutil.RemoteURL(p)
+        if err != nil || url == "" { m.status = "no remote"; return m, nil }
+        err = m.launch(p, "open URL", func() (*exec.Cmd, error) { return exec.Command("xdg-open", url), nil })
+        if err != nil { m.status = "open: " + err.Error(); return m, nil }
+        m.status = "opened remote"
+        return m, nil
+    case "copy_url":
+        // copy remote URL
+        p := m.currentPath()
+        if p == "" { m.status = "no selection"; return m, nil }
+        url, err := gitutil.RemoteURL(p)
+        if err != nil || url == "" { m.status = "no remote"; return m, nil }
+        if err := copyClipboard(url); err != nil { m.status = "copy: " + err.Error() } else { m.status = "copied URL" }
+        return m, nil
+    case "agents":
+        m.showAgents = true
+        // Rebuild items in case config changed while running
+        m.agents.SetItems(m.agentItems())
+        m.status = ""
+        return m, nil
+    case "agent":
+        // a repo's .workflow.yml may pick its own default agent
+        defaultAgent := func(path string) (string, config.Config) {
+            cfg := m.agentCfg(path)
+            if cfg.Agents.Default == "" { return "claude", cfg }
+            return cfg.Agents.Default, cfg
+        }
+        if len(m.selected) > 0 {
+            muxed := m.launchMode(m.cfg.Terminal.ModeFor("agent")) == "mux"
+            return m, runEach("default agent", m.targets(), func(e scanner.RepoEntry) (string, error) {
+                agent, cfg := defaultAgent(e.Path)
+                if muxed { return m.startMux(e.Path, "agent "+agent, agents.BuildAgentCommand(agent, e.Path, cfg)) }
+                return "launched " + agent, m.launch(e.Path, "agent "+agent, func() (*exec.Cmd, error) {
+                    return run.AgentCmd(e.Path, agent, cfg)
+                })
+            })
+        }
+        path := m.currentPath()
+        if path == "" { m.status = "no selection"; return m, nil }
+        agent, _ := defaultAgent(path)
+        return m, m.openAgent(path, agent)
+    default:
+        // user actions can't take navigation keys from the table
+        if !m.tableKey(msg) {
+            if cmd, ok := m.keyAction(msg.String()); ok { return m, cmd }
+        }
+        var cmd tea.Cmd
+        cur := m.table.Cursor()
+        m.table, cmd = m.table.Update(msg)
+        // highlights skip the selected row, so re-render when it moves
+        if m.query.Ranked() && m.table.Cursor() != cur { m.refreshRows() }
+        return m, cmd
     }
-    return m, nil
+}
+
+// fullOverlay reports whether a panel takes the place of the table.
+func (m Model) fullOverlay() bool {
+    return m.showDetail || m.showRun || m.showMdView || m.showDiff
+}
+
+// overlayOpen reports whether a picker or panel covers the bottom of the screen.
+func (m Model) overlayOpen() bool {
+    return m.showAgents || m.showTasks || m.showActions || m.showPalette || m.showMarkdown || m.showMdView || m.showChanges || m.showBranches || m.showDetail || m.showResults || m.showJobs || m.showRun
 }
 
 func (m Model) View() string {
@@ -531,7 +1104,11 @@ func (m Model) View() string {
     // grouped view is now default, no indicator needed
     title += fmt.Sprintf("  [sort:%s%s]", m.sortKey, map[bool]string{true:"↑", false:"↓"}[m.sortAsc])
     if m.scanning {
-        fmt.Fprintln(&b, titleStyle.Render(m.spin.View()+" "+title))
+        prog := ""
+        if m.scanProgress.Total > 0 {
+            prog = fmt.Sprintf(" %d/%d", m.scanProgress.Done, m.scanProgress.Total)
+        }
+        fmt.Fprintln(&b, titleStyle.Render(m.spin.View()+prog+" "+title))
     } else {
         fmt.Fprintln(&b, titleStyle.Render(title))
     }
@@ -541,13 +1118,13 @@ func (m Model) View() string {
 
     if !m.reposLoaded {
         fmt.Fprintln(&b, "loading repos…")
-    } else if len(m.table.Rows()) == 0 && !m.showDetail {
+    } else if len(m.table.Rows()) == 0 && !m.fullOverlay() {
         fmt.Fprintln(&b, "no projects found under configured roots")
-    } else if !m.showDetail {
-        fmt.Fprintln(&b, m.table.View())
+    } else if !m.fullOverlay() {
+        fmt.Fprintln(&b, m.withPreview(m.tableView()))
     }
 
-    overlayOpen := m.showAgents || m.showTasks || m.showMarkdown || m.showDetail
+    overlayOpen := m.overlayOpen()
     if !overlayOpen {
         if m.filtering {
             fmt.Fprintln(&b)
@@ -578,14 +1155,18 @@ func (m Model) View() string {
 
     if m.showHelp && !overlayOpen {
         fmt.Fprintln(&b)
-        fmt.Fprintln(&b, "j/k move  g/G home/end  / filter  R refresh  s/S sort  x expand  ? help  q quit")
-        fmt.Fprintln(&b, "Enter details  r tasks  d docs  e nvim  E GUI editor  o new shell  l lazygit  f fetch  a/A agents  y copy  u open URL  Y copy URL")
+        for _, ln := range m.helpLines() { fmt.Fprintln(&b, ln) }
+        if h := m.actionsHelp(); h != "" { fmt.Fprintln(&b, "Actions: "+h) }
         // badges legend
         fmt.Fprintln(&b)
-        legend := fmt.Sprintf("Badges: [%s dirty] [%s conflicts] [%s ahead] [%s behind] [%s detached] [%s parent] [%s pkg]",
-            colorBadge("*", m.th, "red"), colorBadge("‼", m.th, "red"), colorBadge("⇡", m.th, "green"),
-            colorBadge("⇣", m.th, "yellow"), colorBadge("det", m.th, "magenta"), colorBadge("mono", m.th, "blue"),
-            colorBadge("pkg", m.th, "cyan"),
+        legend := fmt.Sprintf("Badges: [%s dirty] [%s staged] [%s untracked] [%s conflicts] [%s stash] [%s ahead] [%s behind] [%s no upstream] [%s unpushed work] [%s detached] [%s in progress] [%s parent] [%s pkg] [%s cached] [%s fetching] [%s session]",
+            colorBadge("*", m.th, "red"), colorBadge("+", m.th, "green"), colorBadge("?", m.th, "yellow"),
+            colorBadge("‼", m.th, "red"), colorBadge("$", m.th, "cyan"), colorBadge("⇡", m.th, "green"),
+            colorBadge("⇣", m.th, "yellow"), colorBadge("⊘", m.th, "magenta"), colorBadge("⇪", m.th, "red"),
+            colorBadge("det", m.th, "magenta"),
+            colorBadge("rebase", m.th, "red"), colorBadge("mono", m.th, "blue"),
+            colorBadge("pkg", m.th, "cyan"), colorBadge("~", m.th, "white"),
+            colorBadge("↻", m.th, "blue"), colorBadge("@", m.th, "green"),
         )
         fmt.Fprintln(&b, legend)
     }
@@ -601,13 +1182,48 @@ func (m Model) View() string {
         fmt.Fprintln(&b)
         fmt.Fprintln(&b, m.markdownItems.View())
     }
+    if m.showActions {
+        fmt.Fprintln(&b)
+        fmt.Fprintln(&b, m.actionItems.View())
+    }
+    if m.showPalette {
+        fmt.Fprintln(&b)
+        fmt.Fprintln(&b, m.paletteList.View())
+    }
+    if m.showChanges {
+        fmt.Fprintln(&b)
+        fmt.Fprintln(&b, m.changesView())
+    }
+    if m.showBranches {
+        fmt.Fprintln(&b)
+        fmt.Fprintln(&b, m.branchesView())
+    }
+    if m.showJobs {
+        fmt.Fprintln(&b)
+        fmt.Fprintln(&b, m.jobsPanelView(m.jobsPanelHeight(m.height-2)))
+    }
+    if m.showResults {
+        fmt.Fprintln(&b)
+        fmt.Fprintln(&b, m.resultsView())
+    }
+    if m.showRun {
+        fmt.Fprintln(&b)
+        fmt.Fprintln(&b, m.taskRunHeader())
+        fmt.Fprintln(&b, m.runView.View())
+    }
     if m.showDetail {
         fmt.Fprintln(&b)
         // Full-screen style details overlay (uses entire content area)
-        head := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(pickAccent(m.th.Colors, m.th.Dark))).Render("Details (Esc to close, d docs)")
+        head := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(pickAccent(m.th.Colors, m.th.Dark))).Render("Details (Esc to close, d docs, c changes)")
         fmt.Fprintln(&b, head)
         fmt.Fprintln(&b, m.detail.View())
     }
+    if m.showMdView {
+        fmt.Fprintln(&b)
+        head := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(pickAccent(m.th.Colors, m.th.Dark))).Render(m.markdownHeader())
+        fmt.Fprintln(&b, head)
+        fmt.Fprintln(&b, m.mdView.View())
+    }
     return b.String()
 }
 
@@ -618,28 +1234,6 @@ func max(a, b int) int {
     return b
 }
 
-func bucket(age string) string {
-    // age is formatted already; use rough mapping
-    if age == "now" { return "active" }
-    if strings.HasSuffix(age, "h") { return "active" }
-    if strings.HasSuffix(age, "d") {
-        // parse days
-        d := 0
-        fmt.Sscanf(age, "%dd", &d)
-        switch {
-        case d <= 3:
-            return "active"
-        case d <= 14:
-            return "warm"
-        case d <= 45:
-            return "stale"
-        default:
-            return "dormant"
-        }
-    }
-    return "stale"
-}
-
 func (m Model) currentPath() string {
     if len(m.visible) == 0 { return "" }
     idx := m.table.Cursor()
@@ -718,55 +1312,93 @@ func (m *Model) refreshRows() {
     // Grouped view is now default - always use grouped logic
     // Build child index and set for quick lookup
     childrenOf := map[string][]int{}
-    childSet := map[int]bool{}
     for i, r := range m.repos {
         if r.WorkspacePkg && r.ParentPath != "" {
             childrenOf[r.ParentPath] = append(childrenOf[r.ParentPath], i)
-            childSet[i] = true
         }
     }
-    // helper to maybe render a row
+    // Evaluate the filter once per repo; free text also yields a fuzzy score
+    // for ranking and the matched characters for highlighting
+    type hit struct {
+        score int
+        idx   []int
+    }
+    hits := map[int]hit{}
+    matches := func(i int) bool {
+        if _, ok := hits[i]; ok { return true }
+        r := m.repos[i]
+        subj := query.NewSubject(m.cfg, r, m.cfg.DisplayName(r.Path))
+        if !m.query.Match(subj) { return false }
+        sc, idx := m.query.Score(subj.Name)
+        hits[i] = hit{sc, idx}
+        return true
+    }
+    // helper to render a row
     addRow := func(i int, r scanner.RepoEntry, indent string) {
-        if m.filter != "" {
-            q := strings.ToLower(m.filter)
-            n := r.Name
-            if r.WorkspacePkg && r.PackageName != "" { n = r.PackageName }
-            if !strings.Contains(strings.ToLower(n), q) && !strings.Contains(strings.ToLower(r.Branch), q) {
-                return
-            }
-        }
         dirty := ""
         isSel := rowNo == sel
         if r.Dirty { dirty = "*" }
         ab := fmt.Sprintf("%d/%d", r.Ahead, r.Behind)
-        state := bucket(r.LastAge)
-        name := m.renderNameSelected(r, indent, isSel)
+        state := scanner.Bucket(r.LastAge)
+        if len(m.selected) > 0 {
+            // selection marker column, only while something is selected
+            mark := "  "
+            if m.selected[r.Path] { mark = "● " }
+            indent = mark + indent
+        }
+        name := m.renderNameSelected(r, indent, isSel, hits[i].idx)
         rows = append(rows, table.Row{name, state, r.Branch, dirty, ab, r.LastAge})
         m.visible = append(m.visible, i)
         rowNo++
     }
+    // A parent is shown when it or any child matches; a matching parent keeps
+    // all of its children visible.
+    type group struct {
+        parent int
+        kids   []int
+        score  int
+    }
+    var groups []group
     for i, r := range m.repos {
         if m.isHidden(r.Path) { continue }
         if r.WorkspacePkg { continue } // will be rendered under parent
-        addRow(i, r, "")
         // Ensure monorepo parents are expanded by default
         if r.Monorepo {
             if _, ok := m.expanded[r.Path]; !ok {
                 m.expanded[r.Path] = true
             }
         }
+        // sort children by name
+        ch := childrenOf[r.Path]
+        sort.SliceStable(ch, func(a, b int) bool {
+            na := m.repos[ch[a]].Name
+            nb := m.repos[ch[b]].Name
+            if m.repos[ch[a]].PackageName != "" { na = m.repos[ch[a]].PackageName }
+            if m.repos[ch[b]].PackageName != "" { nb = m.repos[ch[b]].PackageName }
+            return na < nb
+        })
+        pm := matches(i)
+        g := group{parent: i, score: math.MinInt32}
+        if pm { g.score = hits[i].score }
+        for _, ci := range ch {
+            if m.isHidden(m.repos[ci].Path) { continue }
+            km := matches(ci)
+            if !pm && !km { continue }
+            if km && hits[ci].score > g.score { g.score = hits[ci].score }
+            g.kids = append(g.kids, ci)
+        }
+        if !pm && len(g.kids) == 0 { continue }
+        groups = append(groups, g)
+    }
+    // While typing free text, best matches float to the top
+    if m.query.Ranked() {
+        sort.SliceStable(groups, func(a, b int) bool { return groups[a].score > groups[b].score })
+    }
+    for _, g := range groups {
+        r := m.repos[g.parent]
+        addRow(g.parent, r, "")
         if r.Monorepo && m.expanded[r.Path] {
-                // sort children by name
-                ch := childrenOf[r.Path]
-                sort.SliceStable(ch, func(a, b int) bool {
-                    na := m.repos[ch[a]].Name
-                    nb := m.repos[ch[b]].Name
-                    if m.repos[ch[a]].PackageName != "" { na = m.repos[ch[a]].PackageName }
-                    if m.repos[ch[b]].PackageName != "" { nb = m.repos[ch[b]].PackageName }
-                    return na < nb
-                })
-            for _, ci := range ch {
-                if m.isHidden(m.repos[ci].Path) { continue }
+            for _, ci := range g.kids {
                 addRow(ci, m.repos[ci], "  ")
             }
         }
@@ -775,84 +1407,87 @@ func (m *Model) refreshRows() {
     m.updateTableHeight()
 }
 
-func (m *Model) renderNameSelected(r scanner.RepoEntry, indent string, selected bool) string {
+func (m *Model) renderNameSelected(r scanner.RepoEntry, indent string, selected bool, matched []int) string {
     // determine base display name
     base := r.Name
     if r.WorkspacePkg && r.PackageName != "" { base = r.PackageName }
     // override display name from config
-    if name := m.overrideName(r.Path); name != "" { base = name }
+    if name := m.cfg.DisplayName(r.Path); name != "" { base = name }
+    // Highlight filter matches, except on the selected row where inner
+    // styling would cut through the selection background
+    if len(matched) > 0 && !selected { base = m.highlightMatches(base, matched) }
     // colorized badges
     var parts []string
     if r.Dirty { parts = append(parts, "*") }
+    if r.Staged > 0 { parts = append(parts, "+") }
+    if r.Untracked > 0 { parts = append(parts, "?") }
     if r.Conflicts > 0 { parts = append(parts, "‼") }
+    if r.Stashes > 0 { parts = append(parts, "$") }
     if r.Ahead > 0 { parts = append(parts, "⇡") }
     if r.Behind > 0 { parts = append(parts, "⇣") }
+    if r.NoUpstream { parts = append(parts, "⊘") }
+    if len(r.Unpushed) > 0 { parts = append(parts, "⇪") }
     if strings.HasPrefix(strings.ToLower(r.Branch), "(detached)") { parts = append(parts, "det") }
+    if r.Operation != "" { parts = append(parts, r.Operation) }
     if r.Monorepo { parts = append(parts, "mono") }
     if r.WorkspacePkg { parts = append(parts, "pkg") }
+    if r.Stale { parts = append(parts, "~") }
+    if m.fetching[r.Path] { parts = append(parts, "↻") }
+    if m.sessions.Has(r.Path) { parts = append(parts, "@") }
+    out := indent + base
     if len(parts) > 0 {
-        return indent + fmt.Sprintf("%s [%s]", base, strings.Join(parts, ""))
+        out = indent + fmt.Sprintf("%s [%s]", base, strings.Join(parts, ""))
     }
-    return indent + base
+    return out
 }
 
-func colorBadge(s string, th theme.Theme, key string) string {
-    hex := ""
-    if th.Dark {
-        if v := th.Colors.Bright[key]; v != "" { hex = v }
-    }
-    if hex == "" {
-        if v := th.Colors.Normal[key]; v != "" { hex = v } else if v := th.Colors.Bright[key]; v != "" { hex = v }
+// The table measures and truncates cells without regard to ANSI sequences,
+// so matched characters are only marked with zero-width runes in the rows
+// and styled once the table is laid out. hlOff joins the character before
+// it, so truncation keeps or drops both together.
+const (
+    hlOn  = "\u200b"
+    hlOff = "\u200c"
+)
+
+var hlMarked = regexp.MustCompile(hlOn + "([^" + hlOn + hlOff + "]*)" + hlOff)
+
+// highlightMatches marks the characters at byte offsets idx in s.
+func (m *Model) highlightMatches(s string, idx []int) string {
+    set := make(map[int]bool, len(idx))
+    for _, i := range idx { set[i] = true }
+    var sb strings.Builder
+    for i, r := range s {
+        if set[i] { sb.WriteString(hlOn + string(r) + hlOff) } else { sb.WriteRune(r) }
     }
-    if hex == "" { hex = pickAccent(th.Colors, th.Dark) }
-    st := lipgloss.NewStyle().Foreground(lipgloss.Color(hex))
-    if !th.Dark { st = st.Faint(true) }
-    return st.Render(s)
+    return sb.String()
 }
 
-func (m *Model) isHidden(path string) bool {
-    if len(m.cfg.Overrides) == 0 { return false }
-    if ov, ok := m.cfg.Overrides[path]; ok {
-        if ov.Hidden { return true }
-    }
-    for pat, ov := range m.cfg.Overrides {
-        if strings.ContainsAny(pat, "*?[") {
-            if ok, _ := filepath.Match(pat, path); ok {
-                if ov.Hidden { return true }
-            }
-        }
+// tableView renders the table with the marked matches styled; a mark cut
+// off by truncation is dropped.
+func (m Model) tableView() string {
+    v := m.table.View()
+    if !strings.Contains(v, hlOn) { return v }
+    prefix := func(st lipgloss.Style) string {
+        p, _, _ := strings.Cut(st.Render("x"), "x")
+        return p
     }
-    return false
+    on := prefix(lipgloss.NewStyle().Bold(true).Underline(true).Foreground(lipgloss.Color(pickAccent(m.th.Colors, m.th.Dark))))
+    // back to the cell style
+    off := ""
+    if on != "" { off = "\x1b[0m" + prefix(lipgloss.NewStyle().Foreground(lipgloss.Color(pickFG(m.th.Colors, m.th.Dark)))) }
+    v = hlMarked.ReplaceAllString(v, on+"$1"+off)
+    return strings.NewReplacer(hlOn, "", hlOff, "").Replace(v)
 }
 
-// mapKey maps a pressed key to the internal default binding if configured.
-func (m *Model) mapKey(s string) string {
-    if s == m.cfg.Keys.Group && s != "" { return "m" }
-    if s == m.cfg.Keys.Expand && s != "" { return "x" }
-    if s == m.cfg.Keys.Refresh && s != "" { return "R" }
-    if s == m.cfg.Keys.Sort && s != "" { return "s" }
-    if s == m.cfg.Keys.SortReverse && s != "" { return "S" }
-    if s == m.cfg.Keys.Details && s != "" { return "enter" }
-    if s == m.cfg.Keys.Tasks && s != "" { return "r" }
-    return s
+func colorBadge(s string, th theme.Theme, key string) string {
+    st := lipgloss.NewStyle().Foreground(lipgloss.Color(paletteHex(th, key)))
+    if !th.Dark { st = st.Faint(true) }
+    return st.Render(s)
 }
 
-func (m *Model) overrideName(path string) string {
-    if len(m.cfg.Overrides) == 0 { return "" }
-    // exact path match first
-    if ov, ok := m.cfg.Overrides[path]; ok {
-        if ov.DisplayName != "" { return ov.DisplayName }
-        return ""
-    }
-    // try glob patterns
-    for pat, ov := range m.cfg.Overrides {
-        if strings.ContainsAny(pat, "*?[") {
-            if ok, _ := filepath.Match(pat, path); ok {
-                if ov.DisplayName != "" { return ov.DisplayName }
-            }
-        }
-    }
-    return ""
+func (m *Model) isHidden(path string) bool {
+    return m.cfg.IsHidden(path)
 }
 
 type agentItem struct{
@@ -879,7 +1514,7 @@ type markdownFileItem struct {
 }
 
 func (m markdownFileItem) Title() string { return m.name }
-func (m markdownFileItem) Description() string { return "view with bat" }
+func (m markdownFileItem) Description() string { return "view" }
 func (m markdownFileItem) FilterValue() string { return m.name }
 
 // setupThemedList creates a list with current theme styling
@@ -929,7 +1564,12 @@ func (m *Model) setupThemedList(items []list.Item, title string) list.Model {
 func (m *Model) openMarkdownPicker() {
     path := m.currentPath()
     if path == "" { return }
-    files := scanner.FindMarkdownFiles(path)
+    // docs named in .workflow.yml come first
+    rc, _ := config.LoadRepo(path)
+    files := append([]string{}, rc.Docs...)
+    for _, f := range scanner.FindMarkdownFiles(path) {
+        if !slices.Contains(rc.Docs, f) { files = append(files, f) }
+    }
     if len(files) == 0 {
         m.status = "no markdown files"
         return
@@ -943,6 +1583,14 @@ func (m *Model) openMarkdownPicker() {
     m.showMarkdown = true
 }
 
+// openAgent starts agent for the repo at path with the repo's agent settings.
+func (m *Model) openAgent(path, agent string) tea.Cmd {
+    cfg := m.agentCfg(path)
+    return m.openApp(path, "agent", "agent "+agent, agents.BuildAgentCommand(agent, path, cfg),
+        func() *exec.Cmd { return run.InPlaceCmd(path, agents.BuildAgentCommand(agent, path, cfg)) },
+        func() (*exec.Cmd, error) { return run.AgentCmd(path, agent, cfg) })
+}
+
 func (m *Model) agentItems() []list.Item {
     items := []list.Item{}
     // stable order: default first, then alphabetical others
@@ -950,6 +1598,7 @@ func (m *Model) agentItems() []list.Item {
     for k := range m.cfg.Agents.Map { keys = append(keys, k) }
     sort.Strings(keys)
     def := m.cfg.Agents.Default
+    if p := m.currentPath(); p != "" { def = m.agentCfg(p).Agents.Default }
     if def != "" {
         cmd := m.cfg.Agents.Map[def]
         if cmd == "" { cmd = def }
@@ -967,13 +1616,57 @@ func (m *Model) agentItems() []list.Item {
     return items
 }
 
+// scanCmd starts a streaming scan. Entries arrive as scanEntryMsg while repos
+// finish; the final repoListMsg carries the complete, deduplicated list.
 func scanCmd(cfg config.Config) tea.Cmd {
     return func() tea.Msg {
-        entries, _ := scanner.Scan(cfg)
-        return repoListMsg{Entries: entries}
+        ch := make(chan tea.Msg, 64)
+        go func() {
+            entries, _ := scanner.ScanStream(cfg, func(e scanner.RepoEntry, p scanner.Progress) {
+                ch <- scanEntryMsg{Entry: e, Progress: p, ch: ch}
+            })
+            ch <- repoListMsg{Entries: entries}
+            close(ch)
+        }()
+        return <-ch
     }
 }
 
+// loadCachedCmd loads status snapshots from the previous run.
+func loadCachedCmd(cfg config.Config) tea.Cmd {
+    return func() tea.Msg {
+        return cachedReposMsg{Entries: scanner.Cached(cfg)}
+    }
+}
+
+// scanWaitCmd reads the next streamed scan message.
+func scanWaitCmd(ch chan tea.Msg) tea.Cmd {
+    return func() tea.Msg {
+        msg, ok := <-ch
+        if !ok { return nil }
+        return msg
+    }
+}
+
+// repoIndex returns the index of the repo with path, or -1.
+func (m *Model) repoIndex(path string) int {
+    for i := range m.repos {
+        if m.repos[i].Path == path { return i }
+    }
+    return -1
+}
+
+// upsertRepo replaces the entry with the same path or appends it.
+func (m *Model) upsertRepo(e scanner.RepoEntry) {
+    for i := range m.repos {
+        if m.repos[i].Path == e.Path {
+            m.repos[i] = e
+            return
+        }
+    }
+    m.repos = append(m.repos, e)
+}
+
 // theme watching via polling
 type themeTickMsg struct{ Theme theme.Theme }
 
@@ -1007,6 +1700,8 @@ func (m *Model) applyThemeToUI() {
     selText := bestTextFor(accHex, fgHex, pickBG(m.th.Colors, m.th.Dark))
     st.Selected = st.Selected.Foreground(lipgloss.Color(selText)).Background(lipgloss.Color(accHex))
     m.table.SetStyles(st)
+    // rendered markdown carries the old colors
+    m.mdCache = map[mdKey]mdRendered{}
 }
 
 func (m *Model) setupAgentsList() {
@@ -1027,7 +1722,7 @@ func (m *Model) updateTableHeader() {
     wAB := 7
     wLast := 6
     totalFixed := wState + wBranch + wDelta + wAB + wLast + 5 // padding between columns
-    nameWidth := m.width - totalFixed
+    nameWidth := m.tableWidth() - totalFixed
     if nameWidth < 16 { nameWidth = 16 }
     cols := []table.Column{
         {Title: "Name", Width: nameWidth},
@@ -1039,30 +1734,56 @@ func (m *Model) updateTableHeader() {
     }
     m.table.SetColumns(cols)
     // ensure table width aligns with terminal width for consistent columns
-    m.table.SetWidth(m.width)
+    m.table.SetWidth(m.tableWidth())
 }
 
 func min(a, b int) int { if a<b { return a }; return b }
 
-func loadDetailCmd(r scanner.RepoEntry) tea.Cmd {
+func loadDetailCmd(r scanner.RepoEntry, md ansi.StyleConfig, width int) tea.Cmd {
     return func() tea.Msg {
         // Build detail content lazily (plain text; styling applied in View)
-        return detailMsg{Text: buildDetailPlainText(r)}
+        return detailMsg{Text: buildDetailPlainText(r, md, width)}
     }
 }
 
-func buildDetailPlainText(r scanner.RepoEntry) string {
+// buildDetailPlainText lists the repo's details; the README preview is
+// rendered with md at width.
+func buildDetailPlainText(r scanner.RepoEntry, md ansi.StyleConfig, width int) string {
     var sb strings.Builder
     fmt.Fprintln(&sb, r.Name)
     fmt.Fprintf(&sb, "%s\n", r.Path)
     fmt.Fprintf(&sb, "Branch: %s\n", r.Branch)
+    upstream := r.Upstream
+    if r.NoUpstream { upstream = "(none)" }
+    if upstream != "" { fmt.Fprintf(&sb, "Upstream: %s\n", upstream) }
     fmt.Fprintf(&sb, "Ahead/Behind: %d/%d\n", r.Ahead, r.Behind)
-    fmt.Fprintf(&sb, "Dirty: %v  Conflicts: %d\n", r.Dirty, r.Conflicts)
+    fmt.Fprintf(&sb, "Dirty: %v  Staged: %d  Unstaged: %d  Untracked: %d  Conflicts: %d\n", r.Dirty, r.Staged, r.Unstaged, r.Untracked, r.Conflicts)
+    if r.Stashes > 0 { fmt.Fprintf(&sb, "Stashes: %d\n", r.Stashes) }
+    if r.Operation != "" { fmt.Fprintf(&sb, "In progress: %s\n", r.Operation) }
     fmt.Fprintf(&sb, "Last: %s\n", r.LastAge)
+    if len(r.Unpushed) > 0 {
+        fmt.Fprintln(&sb)
+        fmt.Fprintln(&sb, "Unpushed work")
+        for _, b := range r.Unpushed { fmt.Fprintf(&sb, "• %s — %s\n", b.Name, b.Describe()) }
+    }
+    if files, _ := scanner.ChangedFiles(r.Path); len(files) > 0 {
+        fmt.Fprintln(&sb)
+        fmt.Fprintln(&sb, "Changes (press c)")
+        max := 12
+        if len(files) < max { max = len(files) }
+        for _, f := range files[:max] {
+            name := f.Path
+            if f.Orig != "" { name = f.Orig + " → " + f.Path }
+            fmt.Fprintf(&sb, "%s %s\n", f.Code(), name)
+        }
+        if len(files) > max { fmt.Fprintf(&sb, "… and %d more\n", len(files)-max) }
+    }
     // Tasks preview
     fmt.Fprintln(&sb)
     fmt.Fprintln(&sb, "Tasks (press r to run)")
-    ts := tasks.Detect(r.Path)
+    rc, err := config.LoadRepo(r.Path)
+    if err != nil { fmt.Fprintf(&sb, "(repo config: %v)\n", err) }
+    ts, _ := repoTasks(r.Path)
     if len(ts) == 0 {
         fmt.Fprintln(&sb, "(none)")
     } else {
@@ -1075,6 +1796,15 @@ func buildDetailPlainText(r scanner.RepoEntry) string {
             fmt.Fprintf(&sb, "… and %d more\n", len(ts)-max)
         }
     }
+    if len(rc.Actions) > 0 {
+        fmt.Fprintln(&sb)
+        fmt.Fprintln(&sb, "Actions")
+        for _, a := range rc.Actions {
+            name := a.Name
+            if name == "" { name = a.Cmd }
+            fmt.Fprintf(&sb, "%s  %s — %s\n", a.Key, name, a.Cmd)
+        }
+    }
     // Recent commits
     fmt.Fprintln(&sb)
     fmt.Fprintln(&sb, "Recent commits")
@@ -1089,27 +1819,43 @@ func buildDetailPlainText(r scanner.RepoEntry) string {
     // Docs section - shows README snippet for quick preview, press d for full markdown picker
     fmt.Fprintln(&sb)
     fmt.Fprintln(&sb, "Docs (press d)")
+    for _, d := range rc.Docs { fmt.Fprintln(&sb, "• "+d) }
     readme := scanner.ReadmeSnippet(r.Path, 24)
-    if len(readme) == 0 {
+    if len(readme) == 0 && len(rc.Docs) == 0 {
         fmt.Fprintln(&sb, "(none)")
-    } else {
-        for _, ln := range readme { fmt.Fprintln(&sb, ln) }
+    } else if len(readme) > 0 {
+        src := strings.Join(readme, "\n")
+        if out, err := renderMarkdown(src, md, width); err == nil {
+            fmt.Fprint(&sb, strings.Trim(out, "\n"))
+        } else {
+            fmt.Fprintln(&sb, src)
+        }
     }
     return sb.String()
 }
 
+// styleDetail applies some minimal section styling to plain detail text.
+func (m Model) styleDetail(text string) string {
+    lines := strings.Split(text, "\n")
+    if len(lines) > 0 {
+        lines[0] = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(pickAccent(m.th.Colors, m.th.Dark))).Render(lines[0])
+    }
+    for i, ln := range lines {
+        if ln == "Tasks (press r to run)" || ln == "Recent commits" || ln == "Docs (press d)" || ln == "Changes (press c)" || ln == "Unpushed work" {
+            lines[i] = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(pickAccent(m.th.Colors, m.th.Dark))).Render(ln)
+        }
+    }
+    return strings.Join(lines, "\n")
+}
+
 func (m *Model) renderDetailNow(r scanner.RepoEntry) {
-    m.detail.SetContent(buildDetailPlainText(r))
+    m.detail.SetContent(buildDetailPlainText(r, markdownStyle(m.th), m.detail.Width))
     m.detail.GotoTop()
 }
 
-// NOTE: Markdown rendering removed from inline UI to avoid latency.
-
-// renderReadmeWithBat removed: avoid synchronous blocking in Update path
-
 // updateTableHeight computes table height so the overall view fits in the window.
 func (m *Model) updateTableHeight() {
-    overlayOpen := m.showAgents || m.showTasks || m.showMarkdown || m.showDetail
+    overlayOpen := m.overlayOpen()
     overhead := 0
     // Title + separator always
     overhead += 2
@@ -1118,7 +1864,8 @@ func (m *Model) updateTableHeight() {
         if m.filtering { overhead += 2 }
         if m.status != "" { overhead += 2 }
         if m.reposLoaded { overhead += 2 }
-        if m.showHelp { overhead += 5 }
+        if m.showHelp { overhead += 3 + len(m.helpLines()) }
+        if m.showHelp && m.actionsHelp() != "" { overhead++ }
     }
 
     contentH := m.height - overhead
@@ -1142,6 +1889,36 @@ func (m *Model) updateTableHeight() {
         m.table.SetHeight(tableH)
         return
     }
+    if m.showPalette {
+        ov := m.paletteList.Height()
+        if ov <= 0 { ov = 16 }
+        tableH := contentH - (1 + ov)
+        if tableH < 3 { tableH = 3 }
+        m.table.SetHeight(tableH)
+        return
+    }
+    if m.showActions {
+        ov := m.actionItems.Height()
+        if ov <= 0 { ov = 12 }
+        tableH := contentH - (1 + ov)
+        if tableH < 3 { tableH = 3 }
+        m.table.SetHeight(tableH)
+        return
+    }
+    if m.showJobs {
+        tableH := contentH - (1 + m.jobsPanelHeight(contentH))
+        if tableH < 3 { tableH = 3 }
+        m.table.SetHeight(tableH)
+        return
+    }
+    if m.showResults {
+        // header line + viewport below the table
+        m.results.Height = max(3, min(12, contentH/2))
+        tableH := contentH - (2 + m.results.Height)
+        if tableH < 3 { tableH = 3 }
+        m.table.SetHeight(tableH)
+        return
+    }
     if m.showMarkdown {
         ov := m.markdownItems.Height()
         if ov <= 0 { ov = 12 }
@@ -1150,6 +1927,42 @@ func (m *Model) updateTableHeight() {
         m.table.SetHeight(tableH)
         return
     }
+    if m.showDiff {
+        // blank + header line above, status line below
+        m.diffView.Height = max(3, contentH-3)
+        m.table.SetHeight(1)
+        return
+    }
+    if m.showChanges {
+        // list, key hints and status line
+        ov := m.changeItems.Height()
+        if ov <= 0 { ov = 14 }
+        tableH := contentH - (3 + ov)
+        if tableH < 3 { tableH = 3 }
+        m.table.SetHeight(tableH)
+        return
+    }
+    if m.showBranches {
+        // list, key hints or name prompt, and status line
+        ov := m.branchItems.Height()
+        if ov <= 0 { ov = 14 }
+        tableH := contentH - (3 + ov)
+        if tableH < 3 { tableH = 3 }
+        m.table.SetHeight(tableH)
+        return
+    }
+    if m.showMdView {
+        // blank + header line, like details
+        m.mdView.Height = max(3, contentH-2)
+        m.table.SetHeight(1)
+        return
+    }
+    if m.showRun {
+        // like details: blank + two header lines, then the output
+        m.runView.Height = max(3, contentH-3)
+        m.table.SetHeight(1)
+        return
+    }
     if m.showDetail {
         // Full overlay with a small header line and spacer printed above the viewport
         // Reserve 2 lines (blank + header), give the rest to the viewport
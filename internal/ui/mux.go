@@ -0,0 +1,83 @@
+package ui
+
+import (
+    "errors"
+    "os/exec"
+    "path/filepath"
+    "strings"
+    "time"
+
+    tea "github.com/charmbracelet/bubbletea"
+    "workflow/internal/mux"
+)
+
+// muxPollInterval is how often live sessions are listed for the table badge.
+const muxPollInterval = 5 * time.Second
+
+// muxSessionsMsg carries the live multiplexer sessions; poll schedules the
+// next listing.
+type muxSessionsMsg struct {
+    sessions mux.Sessions
+    poll     bool
+}
+
+// muxListCmd lists live sessions after delay.
+func muxListCmd(mx mux.Mux, delay time.Duration, poll bool) tea.Cmd {
+    if delay <= 0 {
+        return func() tea.Msg { return muxSessionsMsg{sessions: mx.List(), poll: poll} }
+    }
+    return tea.Tick(delay, func(time.Time) tea.Msg { return muxSessionsMsg{sessions: mx.List(), poll: poll} })
+}
+
+// openMux opens the repo at path in tmux or zellij, running shellCmd or a
+// shell when it is empty. Attaching from outside the multiplexer suspends
+// the TUI until the client detaches.
+func (m *Model) openMux(path, label, shellCmd string) tea.Cmd {
+    mx, ok := mux.Detect(m.cfg.Mux.Backend)
+    if !ok {
+        m.status = label + ": tmux/zellij not found (mux.backend " + m.cfg.Mux.Backend + ")"
+        return nil
+    }
+    name := filepath.Base(path)
+    if i := m.repoIndex(path); i >= 0 { name = m.repos[i].Name }
+    jobs, target := m.jobs, m.cfg.Mux.Target
+    m.status = "opening " + label + " in " + string(mx.Kind) + "…"
+    return func() tea.Msg {
+        plan := mx.Open(path, name, shellCmd, target)
+        cmds := plan.Setup
+        if plan.Focus != nil { cmds = append(cmds, plan.Focus) }
+        for _, c := range cmds {
+            if _, err := muxRun(jobs, path, name, label, c); err != nil {
+                return execDoneMsg{Path: path, Label: label, Err: err}
+            }
+        }
+        if plan.Attach == nil {
+            return execDoneMsg{Path: path, Label: label, Note: "opened " + label + " in " + string(mx.Kind)}
+        }
+        c := &trackedExec{jobs: jobs, path: path, repo: name, label: label + " (" + string(mx.Kind) + ")", cmd: plan.Attach}
+        return tea.Exec(c, func(err error) tea.Msg { return execDoneMsg{Path: path, Label: label, Err: err} })()
+    }
+}
+
+// startMux opens shellCmd in the repo's multiplexer session without
+// switching to it, for runs over a selection.
+func (m *Model) startMux(path, label, shellCmd string) (string, error) {
+    mx, ok := mux.Detect(m.cfg.Mux.Backend)
+    if !ok { return "", errors.New("tmux/zellij not found") }
+    name := filepath.Base(path)
+    if i := m.repoIndex(path); i >= 0 { name = m.repos[i].Name }
+    for _, c := range mx.Open(path, name, shellCmd, m.cfg.Mux.Target).Setup {
+        if _, err := muxRun(m.jobs, path, name, label, c); err != nil { return "", err }
+    }
+    return "started in " + string(mx.Kind), nil
+}
+
+// muxRun runs one multiplexer command as a job, reporting its output on
+// failure.
+func muxRun(jobs *jobManager, path, repo, label string, c *exec.Cmd) ([]byte, error) {
+    out, err := jobs.run(path, repo, label+" ("+filepath.Base(c.Path)+")", c, nil, 0)
+    if err != nil {
+        if ln, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n"); ln != "" { err = errors.New(ln) }
+    }
+    return out, err
+}
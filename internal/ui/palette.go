@@ -0,0 +1,110 @@
+package ui
+
+import (
+    "os/exec"
+    "sort"
+    "strings"
+
+    "github.com/charmbracelet/bubbles/list"
+    tea "github.com/charmbracelet/bubbletea"
+    "workflow/internal/config"
+    "workflow/internal/gitutil"
+)
+
+// paletteItem is one command palette entry; run executes it on the model as
+// its key would.
+type paletteItem struct {
+    title string
+    desc  string
+    run   func(m Model) (tea.Model, tea.Cmd)
+}
+
+func (p paletteItem) Title() string       { return p.title }
+func (p paletteItem) Description() string { return p.desc }
+func (p paletteItem) FilterValue() string { return p.title + " " + p.desc }
+
+// paletteItems lists the entries for the current repo: context-dependent
+// ones (tasks, actions, agents, pull request) first, then every built-in
+// action with its keys.
+func (m *Model) paletteItems() []list.Item {
+    var items []list.Item
+    path := m.currentPath()
+    if path != "" {
+        ts, _ := repoTasks(path)
+        for _, t := range ts {
+            t := t
+            items = append(items, paletteItem{title: "run task: " + t.Name, desc: t.Cmd, run: func(m Model) (tea.Model, tea.Cmd) {
+                return m, m.runTask(path, t)
+            }})
+        }
+        for _, it := range m.actionsFor(path) {
+            a := it.a
+            desc := a.Cmd
+            if a.Key != "" { desc = keyName(a.Key) + " · " + desc }
+            items = append(items, paletteItem{title: "action: " + a.Label(), desc: desc, run: func(m Model) (tea.Model, tea.Cmd) {
+                return m, m.triggerAction(a)
+            }})
+        }
+        if pr := m.prItem(path); pr != nil { items = append(items, *pr) }
+        names := make([]string, 0, len(m.cfg.Agents.Map))
+        for n := range m.cfg.Agents.Map { names = append(names, n) }
+        sort.Strings(names)
+        for _, n := range names {
+            n := n
+            items = append(items, paletteItem{title: "agent: " + n, desc: m.cfg.Agents.Map[n], run: func(m Model) (tea.Model, tea.Cmd) {
+                return m, m.openAgent(path, n)
+            }})
+        }
+    }
+    for _, b := range config.Bindings {
+        if b.Action == "palette" { continue }
+        action := b.Action
+        var keys []string
+        for _, k := range m.cfg.Keys[action] { keys = append(keys, keyName(k)) }
+        desc := strings.Join(keys, ", ")
+        if desc == "" { desc = "unbound" }
+        items = append(items, paletteItem{title: b.Help, desc: desc, run: func(m Model) (tea.Model, tea.Cmd) {
+            return m.handleKey(action, tea.KeyMsg{Type: tea.KeyRunes}, false)
+        }})
+    }
+    return items
+}
+
+// prItem offers opening a pull request for the current branch when the
+// remote's host is known and the branch isn't a mainline.
+func (m *Model) prItem(path string) *paletteItem {
+    i := m.repoIndex(path)
+    if i < 0 { return nil }
+    branch := m.repos[i].Branch
+    switch branch {
+    case "", "main", "master":
+        return nil
+    }
+    remote, err := gitutil.RemoteURL(path)
+    if err != nil { return nil }
+    url := gitutil.PullRequestURL(remote, branch)
+    if url == "" { return nil }
+    return &paletteItem{title: "open PR for " + branch, desc: url, run: func(m Model) (tea.Model, tea.Cmd) {
+        if err := m.launch(path, "open PR", func() (*exec.Cmd, error) { return exec.Command("xdg-open", url), nil }); err != nil {
+            m.status = "open: " + err.Error()
+        } else {
+            m.status = "opened PR page for " + branch
+        }
+        return m, nil
+    }}
+}
+
+// openPalette shows the command palette, ready for typing.
+func (m *Model) openPalette() {
+    title := "Commands"
+    if i := m.repoIndex(m.currentPath()); i >= 0 { title += " — " + m.repos[i].Name }
+    li := m.setupThemedList(m.paletteItems(), title)
+    li.SetFilteringEnabled(true)
+    li.SetShowFilter(true)
+    // an empty filter lists everything; typing narrows it down
+    li.SetFilterText("")
+    li.SetFilterState(list.Filtering)
+    li.SetSize(min(80, m.width-4), min(16, m.height-6))
+    m.paletteList = li
+    m.showPalette = true
+}
@@ -0,0 +1,99 @@
+package ui
+
+import (
+    "fmt"
+    "time"
+
+    tea "github.com/charmbracelet/bubbletea"
+    "github.com/charmbracelet/lipgloss"
+)
+
+// previewDelay debounces preview loads while the cursor moves.
+const previewDelay = 150 * time.Millisecond
+
+type previewDueMsg struct{ seq int }
+
+type previewMsg struct {
+    want string
+    text string
+}
+
+// splitLayout reports whether the preview pane is shown beside the table.
+func (m Model) splitLayout() bool {
+    w := m.cfg.UI.PreviewMinWidth
+    return w > 0 && m.width >= w
+}
+
+// previewWidth is the width of the preview pane, border included.
+func (m Model) previewWidth() int {
+    if !m.splitLayout() { return 0 }
+    return m.width * 2 / 5
+}
+
+// tableWidth is the width left to the table.
+func (m Model) tableWidth() int { return m.width - m.previewWidth() }
+
+// previewKey identifies what the preview shows: the highlighted repo, the
+// parts of its status the rows show and the pane width.
+func (m Model) previewKey() string {
+    i := m.repoIndex(m.currentPath())
+    if i < 0 { return "" }
+    r := m.repos[i]
+    return fmt.Sprintf("%s|%s|%s|%d|%d|%d|%d|%d|%d|%d|%s|%v|%s|%d", r.Path, r.Branch, r.Upstream, r.Ahead, r.Behind,
+        r.Staged, r.Unstaged, r.Untracked, r.Conflicts, r.Stashes, r.Operation, r.Unpushed, r.LastAge, m.previewWidth())
+}
+
+// schedulePreview starts a debounced load when the preview is out of date.
+func (m *Model) schedulePreview() tea.Cmd {
+    if !m.splitLayout() {
+        m.previewWant = ""
+        return nil
+    }
+    want := m.previewKey()
+    if want == m.previewWant { return nil }
+    m.previewWant = want
+    m.previewSeq++
+    seq := m.previewSeq
+    return tea.Tick(previewDelay, func(time.Time) tea.Msg { return previewDueMsg{seq: seq} })
+}
+
+// loadPreview builds the preview of the highlighted repo in the background
+// once the cursor has settled.
+func (m Model) loadPreview(msg previewDueMsg) tea.Cmd {
+    if msg.seq != m.previewSeq { return nil }
+    i := m.repoIndex(m.currentPath())
+    if i < 0 {
+        return func() tea.Msg { return previewMsg{want: m.previewWant} }
+    }
+    r, want := m.repos[i], m.previewWant
+    style, width := markdownStyle(m.th), m.previewWidth()-2
+    return func() tea.Msg {
+        return previewMsg{want: want, text: buildDetailPlainText(r, style, width)}
+    }
+}
+
+// setPreview shows a loaded preview unless the cursor has moved on.
+func (m *Model) setPreview(msg previewMsg) {
+    if msg.want != m.previewWant { return }
+    m.preview.SetContent(m.styleDetail(msg.text))
+    m.preview.GotoTop()
+}
+
+// previewView renders the pane beside a table view of the given height.
+func (m Model) previewView(height int) string {
+    w := m.previewWidth()
+    pv := m.preview
+    pv.Width, pv.Height = w-2, height
+    return lipgloss.NewStyle().
+        BorderStyle(lipgloss.NormalBorder()).BorderLeft(true).
+        BorderForeground(headerStyle.GetForeground()).
+        PaddingLeft(1).Width(w - 1).
+        Render(pv.View())
+}
+
+// withPreview puts the preview pane to the right of the table view.
+func (m Model) withPreview(tableView string) string {
+    if !m.splitLayout() { return tableView }
+    return lipgloss.JoinHorizontal(lipgloss.Top, tableView, m.previewView(lipgloss.Height(tableView)))
+}
+
@@ -0,0 +1,245 @@
+package ui
+
+import (
+    "os"
+    "path/filepath"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/fsnotify/fsnotify"
+    tea "github.com/charmbracelet/bubbletea"
+    "workflow/internal/scanner"
+)
+
+const (
+    repoWatchDebounce   = 400 * time.Millisecond
+    repoPollInterval    = 5 * time.Second
+    repoTrackedInterval = 30 * time.Second
+)
+
+// repoWatcher watches git internals and the working tree root of every repo
+// and reports debounced changes per repo path. Repos whose directories cannot
+// be watched (e.g. inotify limits reached) are polled instead. Watches and
+// polls don't reach into subdirectories, so every repo also gets a slower
+// git status of its tracked files.
+type repoWatcher struct {
+    w   *fsnotify.Watcher // nil if fsnotify is unavailable
+    out chan string
+
+    mu      sync.Mutex
+    byDir   map[string]map[string]struct{} // watched dir -> repo paths
+    dirsOf  map[string][]string            // repo path -> watched dirs
+    polled  map[string]string              // repo path -> last poll fingerprint
+    tracked map[string]string              // repo path -> last scanner.TrackedKey
+    timers  map[string]*time.Timer
+}
+
+func newRepoWatcher() *repoWatcher {
+    rw := &repoWatcher{
+        out:     make(chan string, 64),
+        byDir:   map[string]map[string]struct{}{},
+        dirsOf:  map[string][]string{},
+        polled:  map[string]string{},
+        tracked: map[string]string{},
+        timers:  map[string]*time.Timer{},
+    }
+    if w, err := fsnotify.NewWatcher(); err == nil {
+        rw.w = w
+        go rw.loop()
+    }
+    go rw.pollLoop()
+    go rw.trackedLoop()
+    return rw
+}
+
+// watchDirs lists the directories to watch for a repo: the git dir (HEAD,
+// index, FETCH_HEAD, packed-refs), local and remote-tracking refs, and the
+// working tree root. Watches are not recursive.
+func watchDirs(path string) []string {
+    dirs := []string{path}
+    gd := scanner.GitDir(path)
+    if gd == "" { return dirs }
+    dirs = append(dirs, gd, filepath.Join(gd, "refs", "heads"))
+    remotes := filepath.Join(gd, "refs", "remotes")
+    if ents, err := os.ReadDir(remotes); err == nil {
+        for _, e := range ents {
+            if e.IsDir() { dirs = append(dirs, filepath.Join(remotes, e.Name())) }
+        }
+    }
+    return dirs
+}
+
+// Sync makes the watch set match paths: new repos are added, vanished ones removed.
+func (rw *repoWatcher) Sync(paths []string) {
+    want := make(map[string]struct{}, len(paths))
+    for _, p := range paths { want[p] = struct{}{} }
+    rw.mu.Lock()
+    defer rw.mu.Unlock()
+    for p := range rw.dirsOf {
+        if _, ok := want[p]; !ok { rw.removeLocked(p) }
+    }
+    for p := range rw.polled {
+        if _, ok := want[p]; !ok { delete(rw.polled, p) }
+    }
+    for p := range rw.tracked {
+        if _, ok := want[p]; !ok { delete(rw.tracked, p) }
+    }
+    for _, p := range paths {
+        if _, ok := rw.tracked[p]; !ok { rw.tracked[p] = "" }
+        if _, ok := rw.dirsOf[p]; ok { continue }
+        if _, ok := rw.polled[p]; ok { continue }
+        rw.addLocked(p)
+    }
+}
+
+func (rw *repoWatcher) addLocked(path string) {
+    if rw.w == nil {
+        rw.polled[path] = pollKey(path)
+        return
+    }
+    var added []string
+    for _, d := range watchDirs(path) {
+        if fi, err := os.Stat(d); err != nil || !fi.IsDir() { continue }
+        if rw.byDir[d] == nil {
+            if err := rw.w.Add(d); err != nil {
+                // Typically ENOSPC/EMFILE when inotify limits are exhausted:
+                // undo partial watches and degrade this repo to polling.
+                rw.dirsOf[path] = added
+                rw.removeLocked(path)
+                rw.polled[path] = pollKey(path)
+                return
+            }
+            rw.byDir[d] = map[string]struct{}{}
+        }
+        rw.byDir[d][path] = struct{}{}
+        added = append(added, d)
+    }
+    rw.dirsOf[path] = added
+}
+
+func (rw *repoWatcher) removeLocked(path string) {
+    for _, d := range rw.dirsOf[path] {
+        set := rw.byDir[d]
+        delete(set, path)
+        if len(set) == 0 {
+            delete(rw.byDir, d)
+            _ = rw.w.Remove(d)
+        }
+    }
+    delete(rw.dirsOf, path)
+}
+
+func (rw *repoWatcher) loop() {
+    for {
+        select {
+        case ev, ok := <-rw.w.Events:
+            if !ok { return }
+            rw.handle(ev)
+        case _, ok := <-rw.w.Errors:
+            if !ok { return }
+            // ignore; overflow or transient errors are covered by the next event
+        }
+    }
+}
+
+func (rw *repoWatcher) handle(ev fsnotify.Event) {
+    base := filepath.Base(ev.Name)
+    // lock files come and go around every git write; the rename that follows is enough
+    if strings.HasSuffix(base, ".lock") { return }
+    if ev.Op == fsnotify.Chmod { return }
+    dir := filepath.Dir(ev.Name)
+    rw.mu.Lock()
+    defer rw.mu.Unlock()
+    for p := range rw.byDir[dir] { rw.triggerLocked(p) }
+    // events on a watched directory itself (e.g. removed) map directly
+    for p := range rw.byDir[ev.Name] { rw.triggerLocked(p) }
+}
+
+// triggerLocked (re)arms the debounce timer for a repo.
+func (rw *repoWatcher) triggerLocked(path string) {
+    if t, ok := rw.timers[path]; ok {
+        // a timer that has fired but not yet taken mu is about to report
+        // the repo, which covers this event; re-arming it would report the
+        // repo a second time
+        if t.Stop() { t.Reset(repoWatchDebounce) }
+        return
+    }
+    rw.timers[path] = time.AfterFunc(repoWatchDebounce, func() {
+        rw.mu.Lock()
+        delete(rw.timers, path)
+        rw.mu.Unlock()
+        rw.out <- path
+    })
+}
+
+// pollKey extends the git fingerprint (which includes the index mtime) with
+// the working tree root mtime so polled repos also notice files being added
+// or removed at the top level.
+func pollKey(path string) string {
+    var mt int64
+    if fi, err := os.Stat(path); err == nil { mt = fi.ModTime().UnixNano() }
+    return scanner.Fingerprint(path) + "/" + strconv.FormatInt(mt, 36)
+}
+
+func (rw *repoWatcher) pollLoop() {
+    t := time.NewTicker(repoPollInterval)
+    defer t.Stop()
+    for range t.C {
+        rw.mu.Lock()
+        paths := make([]string, 0, len(rw.polled))
+        for p := range rw.polled { paths = append(paths, p) }
+        rw.mu.Unlock()
+        for _, p := range paths {
+            key := pollKey(p)
+            rw.mu.Lock()
+            prev, ok := rw.polled[p]
+            if ok && prev != key {
+                rw.polled[p] = key
+                rw.triggerLocked(p)
+            }
+            rw.mu.Unlock()
+        }
+    }
+}
+
+// trackedLoop reports repos whose tracked files changed since the last
+// round, one git status at a time. A repo's first round only records it.
+func (rw *repoWatcher) trackedLoop() {
+    t := time.NewTicker(repoTrackedInterval)
+    defer t.Stop()
+    for range t.C {
+        rw.mu.Lock()
+        paths := make([]string, 0, len(rw.tracked))
+        for p := range rw.tracked { paths = append(paths, p) }
+        rw.mu.Unlock()
+        for _, p := range paths {
+            key := scanner.TrackedKey(p)
+            if key == "" { continue }
+            rw.mu.Lock()
+            prev, ok := rw.tracked[p]
+            if ok && prev != key {
+                rw.tracked[p] = key
+                if prev != "" { rw.triggerLocked(p) }
+            }
+            rw.mu.Unlock()
+        }
+    }
+}
+
+type repoChangedMsg struct{ Path string }
+type repoUpdatedMsg struct{ Entry scanner.RepoEntry }
+
+func repoWatchWaitCmd(rw *repoWatcher) tea.Cmd {
+    return func() tea.Msg {
+        return repoChangedMsg{Path: <-rw.out}
+    }
+}
+
+// refreshRepoCmd re-collects status for a single repo off the Update path.
+func refreshRepoCmd(e scanner.RepoEntry) tea.Cmd {
+    return func() tea.Msg {
+        return repoUpdatedMsg{Entry: scanner.Refresh(e)}
+    }
+}
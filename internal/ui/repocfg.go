@@ -0,0 +1,25 @@
+package ui
+
+import (
+    "workflow/internal/config"
+    "workflow/internal/tasks"
+)
+
+// repoTasks returns the tasks of the repo's .workflow.yml followed by the
+// detected ones they don't shadow. The tasks are returned even when the repo
+// config fails to load.
+func repoTasks(path string) ([]tasks.Task, error) {
+    rc, err := config.LoadRepo(path)
+    custom := make([]tasks.Task, 0, len(rc.Tasks))
+    for _, n := range rc.TaskNames() {
+        t := rc.Tasks[n]
+        custom = append(custom, tasks.Task{Name: n, Cmd: t.Cmd, Src: "repo", Cwd: t.Cwd, Env: t.Env})
+    }
+    return tasks.Merge(custom, tasks.Detect(path)), err
+}
+
+// agentCfg returns the config with the repo's default agent and prelude.
+func (m Model) agentCfg(path string) config.Config {
+    rc, _ := config.LoadRepo(path)
+    return rc.Apply(m.cfg)
+}
@@ -41,6 +41,17 @@ func pickAccent(p theme.Palette, dark bool) string {
     return "#000000"
 }
 
+// paletteHex returns the theme's color named key (e.g. "red"), preferring
+// the bright variant on dark themes, or the accent when it has none.
+func paletteHex(th theme.Theme, key string) string {
+    if th.Dark {
+        if v := th.Colors.Bright[key]; v != "" { return v }
+    }
+    if v := th.Colors.Normal[key]; v != "" { return v }
+    if v := th.Colors.Bright[key]; v != "" { return v }
+    return pickAccent(th.Colors, th.Dark)
+}
+
 func pickFG(p theme.Palette, dark bool) string {
     if p.PrimaryForeground != "" { return p.PrimaryForeground }
     if dark { return "#dddddd" }
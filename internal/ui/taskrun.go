@@ -0,0 +1,247 @@
+package ui
+
+import (
+    "errors"
+    "fmt"
+    "os"
+    "os/exec"
+    "regexp"
+    "strings"
+    "syscall"
+    "time"
+
+    tea "github.com/charmbracelet/bubbletea"
+    "github.com/charmbracelet/lipgloss"
+    "github.com/creack/pty"
+    "workflow/internal/run"
+    "workflow/internal/tasks"
+)
+
+// taskOutputMax bounds the output kept for the inline task pane.
+const taskOutputMax = 256 << 10
+
+// taskDrainWait is how long the output of an exited task may keep coming
+// before the pty is closed and the exit reported.
+const taskDrainWait = 250 * time.Millisecond
+
+// taskRun is a task running under a pty inside the TUI. Only Update touches
+// it; the reader goroutine communicates through ch.
+type taskRun struct {
+    task  tasks.Task
+    path  string
+    repo  string
+    start time.Time
+    end   time.Time
+    exit  int
+    err   string
+    out   *ringBuffer
+    ptmx  *os.File
+    cmd   *exec.Cmd
+    ch    chan tea.Msg
+    // Ctrl-C presses forwarded; the second one kills the process
+    interrupts int
+}
+
+type taskOutputMsg struct{ run *taskRun }
+type taskExitMsg struct {
+    run  *taskRun
+    exit int
+    err  error
+}
+
+func (r *taskRun) running() bool { return r.end.IsZero() }
+
+// inlineTaskCmd builds the shell command for an inline task run.
+func inlineTaskCmd(path string, t tasks.Task) *exec.Cmd {
+    cmd := exec.Command("bash", "-lc", t.Shell())
+    cmd.Dir = path
+    cmd.Env = append(os.Environ(), "TERM=xterm-256color", "CLICOLOR_FORCE=1", "FORCE_COLOR=1")
+    return cmd
+}
+
+// taskMode resolves tasks.mode to "inline", "new_window" or "mux".
+func (m Model) taskMode() string {
+    mode := m.launchMode(m.cfg.Tasks.Mode)
+    if mode == "in_place" { return "inline" }
+    return mode
+}
+
+// runTask runs t for the repo at path in the configured task mode.
+func (m *Model) runTask(path string, t tasks.Task) tea.Cmd {
+    switch m.taskMode() {
+    case "inline":
+        return tea.Batch(m.startInlineTask(path, t), m.startTicker())
+    case "mux":
+        return m.openMux(path, "task "+t.Name, t.Shell())
+    }
+    err := m.launch(path, "task "+t.Name, func() (*exec.Cmd, error) { return run.ShellCmd(path, t.Shell(), m.cfg) })
+    if err != nil {
+        m.status = "task: " + err.Error()
+    } else {
+        m.status = "task launched: " + t.Name
+    }
+    return nil
+}
+
+// startInlineTask runs t under a pty and opens the output pane.
+func (m *Model) startInlineTask(path string, t tasks.Task) tea.Cmd {
+    if m.taskRun != nil && m.taskRun.running() {
+        m.status = "a task is already running inline (t to view)"
+        return nil
+    }
+    name := path
+    if i := m.repoIndex(path); i >= 0 { name = m.repos[i].Name }
+    r := &taskRun{task: t, path: path, repo: name, out: newRingBuffer(taskOutputMax), ch: make(chan tea.Msg, 64), exit: -1}
+    cmd := inlineTaskCmd(path, t)
+    w, h := m.taskPaneSize()
+    ptmx, err := pty.StartWithSize(cmd, &pty.Winsize{Rows: uint16(h), Cols: uint16(w)})
+    if err != nil {
+        m.status = "task: " + err.Error()
+        return nil
+    }
+    r.cmd, r.ptmx, r.start = cmd, ptmx, time.Now()
+    // listed in the jobs panel; a re-run from there goes to the background
+    j := m.jobs.add(path, name, "task "+t.Name, cmd, func() (*exec.Cmd, error) { return inlineTaskCmd(path, t), nil }, false)
+    m.jobs.started(j)
+    jobs := m.jobs
+    drained := make(chan struct{})
+    go func() {
+        defer close(drained)
+        buf := make([]byte, 4096)
+        for {
+            n, err := ptmx.Read(buf)
+            if n > 0 {
+                r.out.Write(buf[:n])
+                j.out.Write(buf[:n])
+                // coalesce: one pending notification is enough
+                select {
+                case r.ch <- taskOutputMsg{run: r}:
+                default:
+                }
+            }
+            if err != nil { break }
+        }
+    }()
+    // the exit is reported when the task itself exits: a background child
+    // can keep the pty open, and the reader, blocked, long after that
+    go func() {
+        werr := cmd.Wait()
+        select {
+        case <-drained:
+        case <-time.After(taskDrainWait):
+        }
+        ptmx.Close()
+        jobs.finish(j, werr)
+        code := -1
+        if cmd.ProcessState != nil { code = cmd.ProcessState.ExitCode() }
+        var exitErr *exec.ExitError
+        if errors.As(werr, &exitErr) { werr = nil }
+        // r.ch stays open: a reader still running may send to it, and
+        // nothing waits on it after the exit
+        r.ch <- taskExitMsg{run: r, exit: code, err: werr}
+    }()
+    m.taskRun = r
+    m.showRun = true
+    m.runFollow = true
+    m.runView.SetContent("")
+    m.status = "running " + t.Name
+    m.updateTableHeight()
+    return taskWaitCmd(r)
+}
+
+// taskWaitCmd reads the next message of an inline run.
+func taskWaitCmd(r *taskRun) tea.Cmd {
+    return func() tea.Msg {
+        msg, ok := <-r.ch
+        if !ok { return nil }
+        return msg
+    }
+}
+
+// interruptTask forwards Ctrl-C to the task's terminal; a second press kills it.
+func (m *Model) interruptTask() {
+    r := m.taskRun
+    if r == nil || !r.running() { return }
+    r.interrupts++
+    if r.interrupts > 1 {
+        // the task leads its own session (pty.Start uses Setsid); killing the
+        // whole group stops children that would keep the pty open
+        _ = syscall.Kill(-r.cmd.Process.Pid, syscall.SIGKILL)
+        m.status = "task killed"
+        return
+    }
+    _, _ = r.ptmx.Write([]byte{3})
+    m.status = "sent Ctrl-C (again to kill)"
+}
+
+// rerunTask starts the last inline task again.
+func (m *Model) rerunTask() tea.Cmd {
+    r := m.taskRun
+    if r == nil { return nil }
+    if r.running() { m.status = "task still running"; return nil }
+    return m.startInlineTask(r.path, r.task)
+}
+
+// refreshTaskView re-renders the captured output into the pane.
+func (m *Model) refreshTaskView() {
+    if m.taskRun == nil { return }
+    m.runView.SetContent(termText(m.taskRun.out.String()))
+    if m.runFollow { m.runView.GotoBottom() }
+}
+
+// taskPaneSize is the pty size matching the output viewport.
+func (m Model) taskPaneSize() (int, int) {
+    // content area minus the blank line and two header lines
+    return max(20, m.width), max(3, m.height-5)
+}
+
+// resizeTaskPty keeps the pty in step with the terminal size.
+func (m *Model) resizeTaskPty() {
+    if m.taskRun == nil || !m.taskRun.running() { return }
+    w, h := m.taskPaneSize()
+    _ = pty.Setsize(m.taskRun.ptmx, &pty.Winsize{Rows: uint16(h), Cols: uint16(w)})
+}
+
+func (m Model) taskRunHeader() string {
+    r := m.taskRun
+    accent := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(pickAccent(m.th.Colors, m.th.Dark)))
+    title := accent.Render(fmt.Sprintf("%s in %s", r.task.Name, r.repo))
+    var state string
+    switch {
+    case r.running():
+        state = colorBadge("running "+time.Since(r.start).Round(time.Second).String(), m.th, "yellow")
+    case r.err != "":
+        state = colorBadge("error: "+r.err, m.th, "red")
+    case r.exit == 0:
+        state = colorBadge("exit 0", m.th, "green") + " in " + r.end.Sub(r.start).Round(10*time.Millisecond).String()
+    default:
+        state = colorBadge(fmt.Sprintf("exit %d", r.exit), m.th, "red") + " in " + r.end.Sub(r.start).Round(10*time.Millisecond).String()
+    }
+    keys := "ctrl+c interrupt  j/k scroll  G follow  Esc close"
+    if !r.running() { keys = "r re-run  j/k scroll  Esc close" }
+    return title + "  " + state + "  " + statusStyle.Render("$ "+r.task.Cmd) + "\n" + statusStyle.Render(keys)
+}
+
+// Escape sequences other than SGR colors would move the cursor or clear the
+// screen inside the viewport, so they are dropped.
+var (
+    csiRe = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]`)
+    oscRe = regexp.MustCompile(`\x1b\][^\x07\x1b]*(\x07|\x1b\\)`)
+)
+
+// termText turns raw pty output into viewport text: CRLF becomes LF, a bare
+// CR overwrites the line (progress bars) and non-color escapes are removed.
+func termText(raw string) string {
+    raw = oscRe.ReplaceAllString(raw, "")
+    raw = csiRe.ReplaceAllStringFunc(raw, func(s string) string {
+        if strings.HasSuffix(s, "m") { return s }
+        return ""
+    })
+    raw = strings.ReplaceAll(raw, "\r\n", "\n")
+    lines := strings.Split(raw, "\n")
+    for i, ln := range lines {
+        if k := strings.LastIndex(strings.TrimRight(ln, "\r"), "\r"); k >= 0 { ln = ln[k+1:] }
+        lines[i] = strings.TrimRight(ln, "\r")
+    }
+    return strings.Join(lines, "\n")
+}